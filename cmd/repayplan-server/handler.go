@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/linjinrongbb/repaymentPlan/plan"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// 错误码
+const (
+	errorCodeBadRequest       = "BAD_REQUEST"        // 请求体不是合法的 JSON
+	errorCodeMissingField     = "MISSING_FIELD"      // 缺少必填字段
+	errorCodeInvalidParameter = "INVALID_PARAMETER"  // 参数检查不通过
+	errorCodeBodyTooLarge     = "REQUEST_TOO_LARGE"  // 请求体超过大小限制
+	errorCodeMethodNotAllowed = "METHOD_NOT_ALLOWED" // 请求方法错误
+	errorCodeInternal         = "INTERNAL_ERROR"     // 计算失败
+)
+
+// errorResponse 错误响应
+type errorResponse struct {
+	Code    string `json:"code"`            // 错误码
+	Message string `json:"message"`         // 错误信息
+	Field   string `json:"field,omitempty"` // 出错的字段
+	// 参数检查不通过时的全部参数错误
+	Errors plan.ValidationErrors `json:"errors,omitempty"`
+}
+
+// 还款计划服务的路由 calendar 为节假日日历,为空时不支持还款日调整
+func newHandler(maxBodyBytes int64, calendar plan.HolidayCalendar) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", handleHealth)
+	mux.HandleFunc("/v1/repayment-methods", handleRepaymentMethods)
+	mux.HandleFunc("/v1/repayment-plans", func(w http.ResponseWriter, r *http.Request) {
+		handleRepaymentPlan(w, r, maxBodyBytes, calendar)
+	})
+	return mux
+}
+
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, errorResponse{Code: errorCodeMethodNotAllowed, Message: "method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// repaymentMethod 已注册的还款方式
+type repaymentMethod struct {
+	Code plan.RepayMethod `json:"code"` // 还款方式代码
+	Name string           `json:"name"` // 还款方式名称
+}
+
+// GET /v1/repayment-methods 列出已注册的还款方式
+func handleRepaymentMethods(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errorResponse{Code: errorCodeMethodNotAllowed, Message: "method not allowed"})
+		return
+	}
+	methods := make([]repaymentMethod, 0)
+	for _, method := range plan.RepaymentMethods() {
+		methods = append(methods, repaymentMethod{Code: method.Code(), Name: method.Name()})
+	}
+	writeJSON(w, http.StatusOK, methods)
+}
+
+// POST /v1/repayment-plans 生成还款计划
+func handleRepaymentPlan(w http.ResponseWriter, r *http.Request, maxBodyBytes int64, calendar plan.HolidayCalendar) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errorResponse{Code: errorCodeMethodNotAllowed, Message: "method not allowed"})
+		return
+	}
+	request := &plan.Request{}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			writeError(w, http.StatusRequestEntityTooLarge, errorResponse{Code: errorCodeBodyTooLarge, Message: "request body too large"})
+			return
+		}
+		writeError(w, http.StatusBadRequest, errorResponse{Code: errorCodeBadRequest, Message: "request body error: " + err.Error()})
+		return
+	}
+	if field := getMissingRequiredField(request); field != "" {
+		writeError(w, http.StatusBadRequest, errorResponse{Code: errorCodeMissingField, Message: field + " can not be empty", Field: field})
+		return
+	}
+	// 节假日日历不在请求体中,由服务启动时加载后注入
+	if calendar != nil {
+		request.Calendar = calendar
+	} else if request.BusinessDayConvention != "" && request.BusinessDayConvention != plan.BusinessDayUnadjusted {
+		status, body := getErrorStatus(&plan.ValidationError{Field: "businessDayConvention", Code: plan.CodeUnsupported,
+			Message: "business Day Convention error: server started without holiday file"})
+		writeError(w, status, body)
+		return
+	}
+	response, err := plan.CalculateRepaymentPlan(request)
+	if err != nil {
+		status, body := getErrorStatus(err)
+		writeError(w, status, body)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// 带 validate:"required" 标签且为零值的第一个字段,返回其 json 名称
+func getMissingRequiredField(request interface{}) string {
+	value := reflect.Indirect(reflect.ValueOf(request))
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Tag.Get("validate") != "required" || !value.Field(i).IsZero() {
+			continue
+		}
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	}
+	return ""
+}
+
+// 参数错误返回 422 和全部参数错误,其他为计算失败
+func getErrorStatus(err error) (int, errorResponse) {
+	var validationErrors plan.ValidationErrors
+	var validationError *plan.ValidationError
+	switch {
+	case errors.As(err, &validationErrors):
+		return http.StatusUnprocessableEntity, errorResponse{Code: errorCodeInvalidParameter, Message: err.Error(),
+			Field: validationErrors[0].Field, Errors: validationErrors}
+	case errors.As(err, &validationError):
+		return http.StatusUnprocessableEntity, errorResponse{Code: errorCodeInvalidParameter, Message: err.Error(),
+			Field: validationError.Field, Errors: plan.ValidationErrors{validationError}}
+	}
+	return http.StatusInternalServerError, errorResponse{Code: errorCodeInternal, Message: err.Error()}
+}
+
+func writeError(w http.ResponseWriter, status int, body errorResponse) {
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/linjinrongbb/repaymentPlan/plan"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testRequestBody = `{
+	"loanAmount": 120000,
+	"loanStartDate": "2022-01-01",
+	"interestRate": 6,
+	"periodNum": 12,
+	"repayDay": 1,
+	"loanCycleCode": "03",
+	"repayMethod": "2",
+	"periodType": "02"
+}`
+
+func doRequest(t *testing.T, method, path, body string) (*httptest.ResponseRecorder, errorResponse) {
+	recorder := httptest.NewRecorder()
+	newHandler(1024, nil).ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	var errorBody errorResponse
+	if recorder.Code != http.StatusOK {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &errorBody); err != nil {
+			t.Fatalf("error body %q: %v", recorder.Body.String(), err)
+		}
+	}
+	return recorder, errorBody
+}
+
+func Test_repaymentPlanHandler(t *testing.T) {
+	recorder, _ := doRequest(t, http.MethodPost, "/v1/repayment-plans", testRequestBody)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d body %s", recorder.Code, recorder.Body.String())
+	}
+	var response plan.Response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.TotalPeriodNum != 12 || len(response.PlanRepayRecords) != 12 || response.TotalInterest.IsZero() {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
+
+func Test_repaymentPlanHandlerError(t *testing.T) {
+	cases := []struct {
+		method string
+		body   string
+		status int
+		code   string
+	}{
+		{http.MethodGet, "", http.StatusMethodNotAllowed, errorCodeMethodNotAllowed},
+		{http.MethodPost, "{", http.StatusBadRequest, errorCodeBadRequest},
+		{http.MethodPost, `{"unknown": 1}`, http.StatusBadRequest, errorCodeBadRequest},
+		{http.MethodPost, `{"loanStartDate": "2022-01-01"}`, http.StatusBadRequest, errorCodeMissingField},
+		{http.MethodPost, strings.Replace(testRequestBody, `"repayDay": 1`, `"repayDay": 40`, 1), http.StatusUnprocessableEntity, errorCodeInvalidParameter},
+		{http.MethodPost, `{"loanStartDate": "` + strings.Repeat("1", 2048) + `"}`, http.StatusRequestEntityTooLarge, errorCodeBodyTooLarge},
+	}
+	for _, c := range cases {
+		recorder, errorBody := doRequest(t, c.method, "/v1/repayment-plans", c.body)
+		if recorder.Code != c.status || errorBody.Code != c.code {
+			t.Errorf("%s %q: got %d %+v, want %d %s", c.method, c.body, recorder.Code, errorBody, c.status, c.code)
+		}
+	}
+}
+
+func Test_repaymentPlanHandlerValidationErrors(t *testing.T) {
+	body := strings.Replace(strings.Replace(testRequestBody, `"repayDay": 1`, `"repayDay": 40`, 1), `"periodType": "02"`, `"periodType": "09"`, 1)
+	recorder, errorBody := doRequest(t, http.MethodPost, "/v1/repayment-plans", body)
+	if recorder.Code != http.StatusUnprocessableEntity || errorBody.Field != "repayDay" || len(errorBody.Errors) != 2 {
+		t.Fatalf("got %d %+v", recorder.Code, errorBody)
+	}
+	if errorBody.Errors[1].Field != "periodType" || errorBody.Errors[1].Code != plan.CodeInvalid {
+		t.Errorf("unexpected errors %+v", errorBody.Errors[1])
+	}
+}
+
+func Test_repaymentMethodsHandler(t *testing.T) {
+	recorder, _ := doRequest(t, http.MethodGet, "/v1/repayment-methods", "")
+	var methods []repaymentMethod
+	if err := json.Unmarshal(recorder.Body.Bytes(), &methods); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Code != http.StatusOK || len(methods) < 6 || methods[0].Code != plan.EqualLoanRepayment || methods[0].Name != "等额本息" {
+		t.Errorf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func Test_healthHandler(t *testing.T) {
+	recorder, _ := doRequest(t, http.MethodGet, "/healthz", "")
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"ok"`) {
+		t.Errorf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+// 期数过多或小数位数过大的请求在生成还款计划前返回 422
+func Test_repaymentPlanHandlerBounds(t *testing.T) {
+	daily := strings.Replace(testRequestBody, `"loanCycleCode": "03"`, `"loanCycleCode": "01"`, 1)
+	cases := []struct {
+		body  string
+		field string
+	}{
+		{strings.Replace(daily, `"periodNum": 12`, `"periodNum": 100000000`, 1), "periodNum"},
+		{strings.Replace(daily, `"periodNum": 12`, `"loanEndDate": "9999-12-31"`, 1), "periodNum"},
+		{strings.Replace(strings.Replace(daily, `"periodNum": 12`, `"periodNum": 100`, 1), `"periodType": "02"`, `"periodType": "01"`, 1), "periodNum"},
+		{strings.Replace(testRequestBody, `"periodNum": 12`, `"periodNum": 12, "roundingPolicy": {"scale": 1000}`, 1), "roundingPolicy.scale"},
+	}
+	for _, c := range cases {
+		recorder, errorBody := doRequest(t, http.MethodPost, "/v1/repayment-plans", c.body)
+		if recorder.Code != http.StatusUnprocessableEntity || errorBody.Code != errorCodeInvalidParameter || errorBody.Field != c.field {
+			t.Errorf("%s: got %d %+v, want 422 %s", c.body, recorder.Code, errorBody, c.field)
+		}
+	}
+}
+
+// 启动时加载的节假日日历注入请求,未加载时不支持还款日调整
+func Test_repaymentPlanHandlerCalendar(t *testing.T) {
+	body := strings.Replace(testRequestBody, `"periodType": "02"`, `"periodType": "02", "businessDayConvention": "following"`, 1)
+	recorder, errorBody := doRequest(t, http.MethodPost, "/v1/repayment-plans", body)
+	if recorder.Code != http.StatusUnprocessableEntity || errorBody.Field != "businessDayConvention" || errorBody.Errors[0].Code != plan.CodeUnsupported {
+		t.Errorf("got %d %+v, want 422 businessDayConvention unsupported", recorder.Code, errorBody)
+	}
+
+	calendar, err := plan.ParseHolidayCalendar(strings.NewReader("2022-10-03\n2022-10-04\n2022-10-05\n2022-10-06\n2022-10-07\n2022-10-08 workday\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder = httptest.NewRecorder()
+	newHandler(1024, calendar).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/repayment-plans", strings.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d body %s", recorder.Code, recorder.Body.String())
+	}
+	var response plan.Response
+	if err = json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	// 2022-10-01 为周六,顺延至国庆假期后的调休工作日
+	if response.PlanRepayRecords[8].PeriodRepayDate != "2022-10-08" {
+		t.Errorf("got period 9 repay date %s, want 2022-10-08", response.PlanRepayRecords[8].PeriodRepayDate)
+	}
+}
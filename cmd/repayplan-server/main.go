@@ -0,0 +1,62 @@
+// repayplan-server 以 HTTP JSON 接口提供还款计划生成服务
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"github.com/linjinrongbb/repaymentPlan/plan"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultAddr         = ":8080"
+	defaultMaxBodyBytes = 1 << 20
+	shutdownTimeout     = 10 * time.Second
+)
+
+func main() {
+	addr := flag.String("addr", defaultAddr, "监听地址")
+	maxBodyBytes := flag.Int64("max-body-bytes", defaultMaxBodyBytes, "请求体大小上限(字节)")
+	holidayFile := flag.String("holiday-file", "", "节假日文件 设置后请求可按 businessDayConvention 调整还款日")
+	flag.Parse()
+
+	var calendar plan.HolidayCalendar
+	if *holidayFile != "" {
+		memoryCalendar, err := plan.LoadHolidayCalendar(*holidayFile)
+		if err != nil {
+			log.Fatalf("holiday file error: %v", err)
+		}
+		calendar = memoryCalendar
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           newHandler(*maxBodyBytes, calendar),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	go func() {
+		log.Printf("repayment plan server listening on %s", *addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("listen error: %v", err)
+		}
+	}()
+
+	// 收到退出信号后等待处理中的请求完成
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Fatalf("shutdown error: %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+// repayplan 命令行生成还款计划,按表格、JSON、CSV、XLSX、PDF 或 iCalendar 输出
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/linjinrongbb/repaymentPlan/export"
+	"github.com/linjinrongbb/repaymentPlan/plan"
+	"github.com/shopspring/decimal"
+	"io"
+	"os"
+	"strings"
+)
+
+// 退出码
+const (
+	exitOK         = 0
+	exitValidation = 1 // 参数检查不通过
+	exitUsage      = 2 // 命令行参数错误
+	exitRuntime    = 3 // 计算或输出失败
+)
+
+// 命令行选项
+type options struct {
+	file         string // 请求参数 JSON 文件,- 为标准输入
+	format       string // 输出格式 table json csv xlsx pdf ics
+	language     string // csv xlsx pdf ics 的语言 zh en
+	fontFile     string // pdf 使用的 TrueType 字体文件
+	loanID       string // ics 的贷款编号
+	reminderDays int    // ics 提前几天提醒
+	holidayFile  string // 节假日文件
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	// 先解析一次得到 JSON 文件,读取文件后再解析一次,命令行参数覆盖文件中的同名字段
+	request, opts := &plan.Request{}, &options{}
+	if err := newFlagSet(request, opts, stderr).Parse(args); err != nil {
+		return exitUsage
+	}
+	if opts.file != "" {
+		request = &plan.Request{}
+		if err := readRequestFile(opts.file, stdin, request); err != nil {
+			fmt.Fprintln(stderr, "repayplan:", err)
+			return exitUsage
+		}
+		if err := newFlagSet(request, opts, stderr).Parse(args); err != nil {
+			return exitUsage
+		}
+	}
+	if opts.holidayFile != "" {
+		calendar, err := plan.LoadHolidayCalendar(opts.holidayFile)
+		if err != nil {
+			fmt.Fprintln(stderr, "repayplan:", err)
+			return exitUsage
+		}
+		request.Calendar = calendar
+	}
+	render, err := getRender(opts)
+	if err != nil {
+		fmt.Fprintln(stderr, "repayplan:", err)
+		return exitUsage
+	}
+
+	response, err := plan.CalculateRepaymentPlan(request)
+	if err != nil {
+		printError(stderr, err)
+		if !isValidationError(err) {
+			return exitRuntime
+		}
+		return exitValidation
+	}
+	if err = render(stdout, response); err != nil {
+		fmt.Fprintln(stderr, "repayplan:", err)
+		return exitRuntime
+	}
+	return exitOK
+}
+
+// 参数检查不通过的错误,其他为计算失败
+func isValidationError(err error) bool {
+	var validationError *plan.ValidationError
+	return errors.As(err, &validationError)
+}
+
+// 参数错误每行一个,带出错的参数名
+func printError(stderr io.Writer, err error) {
+	var validationErrors plan.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		fmt.Fprintln(stderr, "repayplan:", err)
+		return
+	}
+	for _, e := range validationErrors {
+		fmt.Fprintf(stderr, "repayplan: %s: %s\n", e.Field, e.Message)
+	}
+}
+
+func newFlagSet(request *plan.Request, opts *options, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("repayplan", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&opts.file, "file", "", "请求参数 JSON 文件,- 为标准输入,命令行参数覆盖文件中的同名字段")
+	fs.StringVar(&opts.format, "format", formatTable, "输出格式 table json csv xlsx pdf ics")
+	fs.StringVar(&opts.language, "lang", string(export.LanguageChinese), "csv xlsx pdf ics 的语言 zh en")
+	fs.StringVar(&opts.fontFile, "font", "", "pdf 使用的 TrueType 字体文件,需包含中文字形")
+	fs.StringVar(&opts.loanID, "loan-id", "", "ics 的贷款编号,用于生成事件的 UID")
+	fs.IntVar(&opts.reminderDays, "remind-days", 0, "ics 提前几天提醒 0为不提醒")
+	fs.StringVar(&opts.holidayFile, "holiday-file", "", "节假日文件")
+
+	fs.Var(decimalValue{&request.LoanAmount}, "loan-amount", "贷款金额")
+	fs.StringVar(&request.LoanStartDate, "loan-start-date", request.LoanStartDate, "贷款开始日期 yyyy-MM-dd")
+	fs.StringVar(&request.LoanEndDate, "loan-end-date", request.LoanEndDate, "贷款结束日期 yyyy-MM-dd")
+	fs.Var(stringValue[plan.LoanCycleCode]{&request.LoanCycleCode}, "loan-cycle-code", "还款周期频率 01-日 02-两周 03-月 04-季 05-年")
+	fs.Var(decimalValue{&request.InterestRate}, "interest-rate", "年利率(%)")
+	fs.Var(stringValue[plan.RepayMethod]{&request.RepayMethod}, "repay-method", "还款方式 "+getRepayMethodUsage())
+	fs.IntVar(&request.PeriodNum, "period-num", request.PeriodNum, "期数")
+	fs.Var(stringValue[plan.PeriodType]{&request.PeriodType}, "period-type", "期数类型 01-年 02-月")
+	fs.IntVar(&request.RepayDay, "repay-day", request.RepayDay, "每一期还款日 1-31")
+	fs.IntVar(&request.RepayMonthOfQuarter, "repay-month-of-quarter", request.RepayMonthOfQuarter, "按季还款时每季的第几个月还款 1-3")
+	fs.IntVar(&request.DaysOfYear, "days-of-year", request.DaysOfYear, "年天数")
+	fs.Var(stringValue[plan.DayCountConvention]{&request.DayCountConvention}, "day-count-convention", "计息基准 ACT/360 ACT/365F ACT/ACT ISDA 30/360 30E/360")
+	fs.Var(stringValue[plan.BusinessDayConvention]{&request.BusinessDayConvention}, "business-day-convention", "还款日调整方式 unadjusted following modified-following preceding")
+	fs.BoolVar(&request.AdjustInterestEndDate, "adjust-interest-end-date", request.AdjustInterestEndDate, "计息结束日随还款日调整")
+	fs.IntVar(&request.GracePeriodNum, "grace-period-num", request.GracePeriodNum, "宽限期期数")
+	fs.Var(stringValue[plan.GraceType]{&request.GraceType}, "grace-type", "宽限期类型 1-只还利息 2-利息计入本金")
+	fs.Var(decimalValue{&request.BalloonAmount}, "balloon-amount", "尾款金额")
+	fs.Var(decimalValue{&request.BalloonPercent}, "balloon-percent", "尾款比例(%)")
+	fs.IntVar(&request.StepPeriodNum, "step-period-num", request.StepPeriodNum, "阶梯还款每隔几期调整一次每期还款金额")
+	fs.Var(decimalValue{&request.StepRate}, "step-rate", "阶梯还款每次调整的比例(%) 负数为递减")
+	fs.Var(decimalValue{&request.StepAmount}, "step-amount", "阶梯还款每次调整的金额 负数为递减")
+	fs.Var(decimalValue{&request.UpfrontFee}, "upfront-fee", "放款时收取的手续费")
+	return fs
+}
+
+// 已注册的还款方式 如 1-等额本息 2-等额本金
+func getRepayMethodUsage() string {
+	methods := plan.RepaymentMethods()
+	usages := make([]string, 0, len(methods))
+	for _, method := range methods {
+		usages = append(usages, string(method.Code())+"-"+method.Name())
+	}
+	return strings.Join(usages, " ")
+}
+
+func readRequestFile(file string, stdin io.Reader, request *plan.Request) error {
+	reader := stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		reader = f
+	}
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		return errors.New("request file error: " + err.Error())
+	}
+	return nil
+}
+
+// 金额、利率类参数
+type decimalValue struct {
+	value *decimal.Decimal
+}
+
+func (v decimalValue) String() string {
+	if v.value == nil {
+		return ""
+	}
+	return v.value.String()
+}
+
+func (v decimalValue) Set(s string) error {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return err
+	}
+	*v.value = d
+	return nil
+}
+
+// 枚举类参数
+type stringValue[T ~string] struct {
+	value *T
+}
+
+func (v stringValue[T]) String() string {
+	if v.value == nil {
+		return ""
+	}
+	return string(*v.value)
+}
+
+func (v stringValue[T]) Set(s string) error {
+	*v.value = T(s)
+	return nil
+}
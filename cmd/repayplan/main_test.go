@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testArgs = []string{
+	"-loan-amount", "120000", "-loan-start-date", "2022-01-01", "-interest-rate", "6", "-period-num", "12",
+	"-repay-day", "1", "-loan-cycle-code", "03", "-repay-method", "2", "-period-type", "02",
+}
+
+func Test_runTable(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(testArgs, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("got exit code %d: %s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	// 4行概要 1行表头 12期
+	if len(lines) != 17 || !strings.HasPrefix(lines[0], "还款方式:等额本金") {
+		t.Fatalf("unexpected table:\n%s", stdout.String())
+	}
+	if getDisplayWidth(lines[4]) != getDisplayWidth(lines[5]) || getDisplayWidth(lines[5]) != getDisplayWidth(lines[16]) {
+		t.Errorf("columns not aligned:\n%s\n%s\n%s", lines[4], lines[5], lines[16])
+	}
+}
+
+func Test_runFileAndCSV(t *testing.T) {
+	file := `{"loanAmount": 120000, "loanStartDate": "2022-01-01", "interestRate": 6, "periodNum": 12,
+		"repayDay": 1, "loanCycleCode": "03", "repayMethod": "1", "periodType": "02"}`
+	var stdout, stderr bytes.Buffer
+	// 命令行参数覆盖文件中的期数
+	code := run([]string{"-file", "-", "-format", "csv", "-period-num", "6"}, strings.NewReader(file), &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("got exit code %d: %s", code, stderr.String())
+	}
+	rows, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 7 || rows[0][0] != "期次" || rows[6][8] != "0" {
+		t.Errorf("unexpected csv %v", rows)
+	}
+}
+
+func Test_runValidationErrors(t *testing.T) {
+	args := append(append([]string{}, testArgs...), "-repay-day", "40", "-period-type", "09")
+	var stdout, stderr bytes.Buffer
+	if code := run(args, nil, &stdout, &stderr); code != exitValidation {
+		t.Fatalf("got exit code %d", code)
+	}
+	want := "repayplan: repayDay: repay Day error\nrepayplan: periodType: period type error\n"
+	if stderr.String() != want {
+		t.Errorf("got stderr %q, want %q", stderr.String(), want)
+	}
+}
+
+// 写入总是失败的输出
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func Test_runRenderError(t *testing.T) {
+	args := append(append([]string{}, testArgs...), "-format", "json")
+	var stderr bytes.Buffer
+	if code := run(args, nil, failingWriter{}, &stderr); code != exitRuntime || !strings.Contains(stderr.String(), "disk full") {
+		t.Errorf("got exit code %d stderr %q, want %d", code, stderr.String(), exitRuntime)
+	}
+}
+
+func Test_runError(t *testing.T) {
+	cases := []struct {
+		args []string
+		code int
+	}{
+		{append(append([]string{}, testArgs...), "-repay-day", "40"), exitValidation},
+		{append(append([]string{}, testArgs...), "-format", "xml"), exitUsage},
+		{append(append([]string{}, testArgs...), "-format", "csv", "-lang", "fr"), exitUsage},
+		{append(append([]string{}, testArgs...), "-format", "pdf"), exitUsage},
+		{append(append([]string{}, testArgs...), "-format", "ics"), exitUsage},
+		{[]string{"-loan-amount", "abc"}, exitUsage},
+		{[]string{"-file", "not-exist.json"}, exitUsage},
+	}
+	for _, c := range cases {
+		var stdout, stderr bytes.Buffer
+		if code := run(c.args, nil, &stdout, &stderr); code != c.code || stderr.Len() == 0 {
+			t.Errorf("%v: got exit code %d stderr %q, want %d", c.args, code, stderr.String(), c.code)
+		}
+	}
+}
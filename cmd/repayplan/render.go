@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/linjinrongbb/repaymentPlan/export"
+	"github.com/linjinrongbb/repaymentPlan/plan"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 输出格式
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+	formatXLSX  = "xlsx"
+	formatPDF   = "pdf"
+	formatICS   = "ics"
+)
+
+// 还款计划的列,与测试中打印的列一致
+var planColumns = []string{"期次", "起息日", "结息日", "还款日", "天数", "本期还款本金", "本期还款利息", "本期还款总金额", "剩余还款金额"}
+
+// 还款方式名称取注册的名称,未注册的使用代码
+func getRepayMethodName(code plan.RepayMethod) string {
+	if method, ok := plan.GetRepaymentMethod(code); ok {
+		return method.Name()
+	}
+	return string(code)
+}
+
+var loanCycleNames = map[plan.LoanCycleCode]string{
+	plan.LoanCycleDaily:       "日",
+	plan.LoanCycleFortnightly: "两周",
+	plan.LoanCycleMonthly:     "月",
+	plan.LoanCycleQuarterly:   "季",
+	plan.LoanCycleYearly:      "年",
+}
+
+type render func(w io.Writer, response *plan.Response) error
+
+func getRender(opts *options) (render, error) {
+	language := export.Language(opts.language)
+	if language != export.LanguageChinese && language != export.LanguageEnglish {
+		return nil, errors.New("lang error: " + opts.language)
+	}
+	exportOptions := export.Options{Language: language}
+	switch opts.format {
+	case formatTable:
+		return renderTable, nil
+	case formatJSON:
+		return renderJSON, nil
+	case formatCSV:
+		return func(w io.Writer, response *plan.Response) error {
+			return export.WriteCSV(w, response, exportOptions)
+		}, nil
+	case formatXLSX:
+		return func(w io.Writer, response *plan.Response) error {
+			return export.WriteXLSX(w, response, exportOptions)
+		}, nil
+	case formatPDF:
+		if opts.fontFile == "" {
+			return nil, errors.New("font error: pdf needs -font")
+		}
+		font, err := export.LoadFont(opts.fontFile)
+		if err != nil {
+			return nil, err
+		}
+		return func(w io.Writer, response *plan.Response) error {
+			return export.WritePDF(w, response, export.PDFOptions{Options: exportOptions, Font: font})
+		}, nil
+	case formatICS:
+		if opts.loanID == "" {
+			return nil, errors.New("loanId error: ics needs -loan-id")
+		}
+		icsOptions := export.ICSOptions{Options: exportOptions, LoanID: opts.loanID, ReminderDays: opts.reminderDays}
+		return func(w io.Writer, response *plan.Response) error {
+			return export.WriteICS(w, response, icsOptions)
+		}, nil
+	}
+	return nil, errors.New("format error: " + opts.format)
+}
+
+func renderJSON(w io.Writer, response *plan.Response) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(response)
+}
+
+// 表头为贷款概要,各列按显示宽度右对齐
+func renderTable(w io.Writer, response *plan.Response) error {
+	summary := fmt.Sprintf("还款方式:%s\n还款频率:%s    年利息：%s    总期数：%d\n日期：%s 至 %s\n贷款金额：%s    利息：%s    总还款金额：%s\n",
+		getRepayMethodName(response.RepayMethod), loanCycleNames[response.LoanCycleCode], response.InterestRate, response.TotalPeriodNum,
+		response.LoanStartDate, response.LoanEndDate, response.LoanAmount, response.TotalInterest, response.TotalRepayAmount)
+	if _, err := io.WriteString(w, summary); err != nil {
+		return err
+	}
+
+	rows := [][]string{planColumns}
+	for _, record := range response.PlanRepayRecords {
+		rows = append(rows, getRecordRow(record))
+	}
+	widths := make([]int, len(planColumns))
+	for _, row := range rows {
+		for i, cell := range row {
+			if width := getDisplayWidth(cell); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = strings.Repeat(" ", widths[i]-getDisplayWidth(cell)) + cell
+		}
+		if _, err := io.WriteString(w, strings.Join(cells, "  ")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getRecordRow(record plan.RepayPlanRecord) []string {
+	return []string{
+		strconv.Itoa(record.PeriodNum), record.PeriodStartDate, record.PeriodEndDate, record.PeriodRepayDate,
+		strconv.Itoa(record.DaysOfPeriod), record.PeriodRepayPrinciple.String(), record.PeriodRepayInterest.String(),
+		record.PeriodRepayTotalAmount.String(), record.MaintainPrinciple.String(),
+	}
+}
+
+// 终端显示宽度:中文等宽字符占两列
+func getDisplayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if r >= 0x1100 && utf8.RuneLen(r) > 1 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
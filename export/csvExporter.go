@@ -0,0 +1,22 @@
+package export
+
+import (
+	"encoding/csv"
+	"github.com/linjinrongbb/repaymentPlan/plan"
+	"io"
+)
+
+// WriteCSV 把还款计划的各期写为 CSV,第一行为表头
+func WriteCSV(w io.Writer, response *plan.Response, options Options) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(getHeaders(options)); err != nil {
+		return err
+	}
+	for _, record := range response.PlanRepayRecords {
+		if err := writer.Write(getRecordRow(record)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
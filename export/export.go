@@ -0,0 +1,192 @@
+// Package export 把还款计划导出为 CSV、XLSX、PDF 和 iCalendar
+package export
+
+import (
+	"github.com/linjinrongbb/repaymentPlan/plan"
+	"github.com/shopspring/decimal"
+	"strconv"
+)
+
+// 默认的金额小数位数
+const defaultAmountScale = 2
+
+// Language 表头语言
+type Language string
+
+// 表头语言
+const (
+	LanguageChinese Language = "zh" // 中文
+	LanguageEnglish Language = "en" // 英文
+)
+
+// Options 导出选项
+type Options struct {
+	Language Language          `json:"language"` // 表头语言 默认中文
+	Headers  map[string]string `json:"headers"`  // 自定义表头 key为列名(与json字段名一致),覆盖默认表头
+}
+
+// 导出的一列:金额、利率等数值列在 XLSX 中按数字格式写入
+type column struct {
+	key       string
+	zh        string
+	en        string
+	isInt     bool // 期次、天数等整数列
+	isDecimal bool
+	isTotal   bool // 合计行是否汇总该列
+	isRate    bool // 利率列
+	isBool    bool // 是否类的列
+	value     func(record plan.RepayPlanRecord) string
+	decimal   func(record plan.RepayPlanRecord) decimal.Decimal
+}
+
+// 还款计划的列,顺序与 RepayPlanRecord 字段一致
+var planColumns = []column{
+	{key: "periodNum", zh: "期次", en: "Period", isInt: true, value: func(r plan.RepayPlanRecord) string { return strconv.Itoa(r.PeriodNum) }},
+	{key: "periodStartDate", zh: "起息日", en: "Start Date", value: func(r plan.RepayPlanRecord) string { return r.PeriodStartDate }},
+	{key: "periodEndDate", zh: "结息日", en: "End Date", value: func(r plan.RepayPlanRecord) string { return r.PeriodEndDate }},
+	{key: "daysOfPeriod", zh: "天数", en: "Days", isInt: true, value: func(r plan.RepayPlanRecord) string { return strconv.Itoa(r.DaysOfPeriod) }},
+	{key: "periodRepayDate", zh: "还款日", en: "Repay Date", value: func(r plan.RepayPlanRecord) string { return r.PeriodRepayDate }},
+	{key: "periodRepayTotalAmount", zh: "本期还款总金额", en: "Total Payment", isDecimal: true, isTotal: true,
+		decimal: func(r plan.RepayPlanRecord) decimal.Decimal { return r.PeriodRepayTotalAmount }},
+	{key: "periodRepayPrinciple", zh: "本期还款本金", en: "Principal", isDecimal: true, isTotal: true,
+		decimal: func(r plan.RepayPlanRecord) decimal.Decimal { return r.PeriodRepayPrinciple }},
+	{key: "periodRepayInterest", zh: "本期还款利息", en: "Interest", isDecimal: true, isTotal: true,
+		decimal: func(r plan.RepayPlanRecord) decimal.Decimal { return r.PeriodRepayInterest }},
+	{key: "maintainPrinciple", zh: "剩余还款金额", en: "Outstanding Principal", isDecimal: true,
+		decimal: func(r plan.RepayPlanRecord) decimal.Decimal { return r.MaintainPrinciple }},
+	{key: "dayCountConvention", zh: "计息基准", en: "Day Count", value: func(r plan.RepayPlanRecord) string { return string(r.DayCountConvention) }},
+	{key: "interestRate", zh: "年利率(%)", en: "Annual Rate (%)", isDecimal: true, isRate: true,
+		decimal: func(r plan.RepayPlanRecord) decimal.Decimal { return r.InterestRate }},
+	{key: "isPrepayment", zh: "提前还款", en: "Prepayment", isBool: true, value: func(r plan.RepayPlanRecord) string { return strconv.FormatBool(r.IsPrepayment) }},
+	{key: "isGracePeriod", zh: "宽限期", en: "Grace Period", isBool: true, value: func(r plan.RepayPlanRecord) string { return strconv.FormatBool(r.IsGracePeriod) }},
+	{key: "capitalizedInterest", zh: "计入本金的利息", en: "Capitalized Interest", isDecimal: true, isTotal: true,
+		decimal: func(r plan.RepayPlanRecord) decimal.Decimal { return r.CapitalizedInterest }},
+	{key: "balloonAmount", zh: "尾款", en: "Balloon", isDecimal: true, isTotal: true,
+		decimal: func(r plan.RepayPlanRecord) decimal.Decimal { return r.BalloonAmount }},
+	{key: "periodRepayFee", zh: "本期费用", en: "Fee", isDecimal: true, isTotal: true,
+		decimal: func(r plan.RepayPlanRecord) decimal.Decimal { return r.PeriodRepayFee }},
+}
+
+// 汇总的一项
+type summaryItem struct {
+	zh     string
+	en     string
+	isRate bool
+	value  func(response *plan.Response, options Options) interface{}
+}
+
+var summaryItems = []summaryItem{
+	{zh: "还款方式", en: "Repay Method", value: func(r *plan.Response, o Options) interface{} {
+		return o.translateName(getRepayMethodNames(r.RepayMethod), string(r.RepayMethod))
+	}},
+	{zh: "还款周期频率", en: "Loan Cycle", value: func(r *plan.Response, o Options) interface{} {
+		return o.translateName(loanCycleNames[r.LoanCycleCode], string(r.LoanCycleCode))
+	}},
+	{zh: "贷款开始日期", en: "Loan Start Date", value: func(r *plan.Response, _ Options) interface{} { return r.LoanStartDate }},
+	{zh: "贷款结束日期", en: "Loan End Date", value: func(r *plan.Response, _ Options) interface{} { return r.LoanEndDate }},
+	{zh: "总期数", en: "Total Periods", value: func(r *plan.Response, _ Options) interface{} { return r.TotalPeriodNum }},
+	{zh: "贷款金额", en: "Loan Amount", value: func(r *plan.Response, _ Options) interface{} { return r.LoanAmount }},
+	{zh: "年利率(%)", en: "Annual Rate (%)", isRate: true, value: func(r *plan.Response, _ Options) interface{} { return r.InterestRate }},
+	{zh: "总还款利息", en: "Total Interest", value: func(r *plan.Response, _ Options) interface{} { return r.TotalInterest }},
+	{zh: "总还款金额", en: "Total Repay Amount", value: func(r *plan.Response, _ Options) interface{} { return r.TotalRepayAmount }},
+	{zh: "费用合计", en: "Total Fees", value: func(r *plan.Response, _ Options) interface{} { return r.TotalFees }},
+	{zh: "年化利率APR(%)", en: "APR (%)", isRate: true, value: func(r *plan.Response, _ Options) interface{} { return r.AnnualPercentageRate }},
+	{zh: "实际年利率EAR(%)", en: "EAR (%)", isRate: true, value: func(r *plan.Response, _ Options) interface{} { return r.EffectiveAnnualRate }},
+}
+
+// 还款方式、还款周期频率的中英文名称
+var repayMethodNames = map[plan.RepayMethod][2]string{
+	plan.EqualLoanRepayment:           {"等额本息", "Equal Installment"},
+	plan.EqualPrincipalRepayment:      {"等额本金", "Equal Principal"},
+	plan.BothPrincipalAndInterest:     {"息随本清", "Bullet"},
+	plan.BeforeInterestAfterPrincipal: {"先息后本", "Interest Only"},
+	plan.EqualPrincipalAndInterest:    {"等本等息", "Flat Rate"},
+	plan.BalloonRepayment:             {"气球贷", "Balloon"},
+	plan.GraduatedRepayment:           {"阶梯还款", "Graduated"},
+	plan.RuleOf78Repayment:            {"78法则", "Rule of 78"},
+	plan.CustomPrincipalRepayment:     {"自定义本金", "Custom Principal"},
+}
+
+// 内置还款方式使用中英文名称,注册的其他还款方式使用注册的名称
+func getRepayMethodNames(code plan.RepayMethod) [2]string {
+	if names, ok := repayMethodNames[code]; ok {
+		return names
+	}
+	if method, ok := plan.GetRepaymentMethod(code); ok {
+		return [2]string{method.Name(), method.Name()}
+	}
+	return [2]string{}
+}
+
+var loanCycleNames = map[plan.LoanCycleCode][2]string{
+	plan.LoanCycleDaily:       {"日", "Daily"},
+	plan.LoanCycleFortnightly: {"两周", "Fortnightly"},
+	plan.LoanCycleMonthly:     {"月", "Monthly"},
+	plan.LoanCycleQuarterly:   {"季", "Quarterly"},
+	plan.LoanCycleYearly:      {"年", "Yearly"},
+}
+
+// 列的表头:自定义表头优先,其次按语言
+func (o Options) getHeader(key, zh, en string) string {
+	if header, ok := o.Headers[key]; ok {
+		return header
+	}
+	return o.translate(zh, en)
+}
+
+func (o Options) translate(zh, en string) string {
+	if o.Language == LanguageEnglish {
+		return en
+	}
+	return zh
+}
+
+// 名称未定义时使用代码
+func (o Options) translateName(names [2]string, code string) string {
+	if names[0] == "" {
+		return code
+	}
+	return o.translate(names[0], names[1])
+}
+
+func getHeaders(options Options) []string {
+	headers := make([]string, 0, len(planColumns))
+	for _, c := range planColumns {
+		headers = append(headers, options.getHeader(c.key, c.zh, c.en))
+	}
+	return headers
+}
+
+// 每一期的各列文本
+func getRecordRow(record plan.RepayPlanRecord) []string {
+	row := make([]string, 0, len(planColumns))
+	for _, c := range planColumns {
+		if c.isDecimal {
+			row = append(row, c.decimal(record).String())
+		} else {
+			row = append(row, c.value(record))
+		}
+	}
+	return row
+}
+
+// 合计行:汇总 isTotal 的列
+func getTotals(response *plan.Response) []decimal.Decimal {
+	totals := make([]decimal.Decimal, len(planColumns))
+	for _, record := range response.PlanRepayRecords {
+		for col, c := range planColumns {
+			if c.isTotal {
+				totals[col] = totals[col].Add(c.decimal(record))
+			}
+		}
+	}
+	return totals
+}
+
+// 金额的小数位数:按舍入规则,未设置时为2位
+func getAmountScale(response *plan.Response) int {
+	if response.RoundingPolicy.Scale != nil {
+		return int(*response.RoundingPolicy.Scale)
+	}
+	return defaultAmountScale
+}
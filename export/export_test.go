@@ -0,0 +1,203 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/linjinrongbb/repaymentPlan/plan"
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/image/font/gofont/goregular"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func getTestResponse(t *testing.T) *plan.Response {
+	response, err := plan.CalculateRepaymentPlan(&plan.Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: plan.LoanCycleMonthly,
+		RepayMethod:   plan.EqualLoanRepayment,
+		PeriodType:    plan.PeriodTypeMonth,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func Test_WriteCSV(t *testing.T) {
+	response := getTestResponse(t)
+	cases := []struct {
+		options Options
+		header  string
+	}{
+		{Options{}, "期次"},
+		{Options{Language: LanguageEnglish}, "Period"},
+		{Options{Language: LanguageEnglish, Headers: map[string]string{"periodNum": "No."}}, "No."},
+	}
+	for _, c := range cases {
+		var buffer bytes.Buffer
+		if err := WriteCSV(&buffer, response, c.options); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(&buffer).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 13 || len(rows[0]) != len(planColumns) || rows[0][0] != c.header {
+			t.Errorf("%+v: unexpected header %v", c.options, rows[0])
+		}
+		if rows[12][8] != "0" {
+			t.Errorf("last maintain principle %s, want 0", rows[12][8])
+		}
+	}
+}
+
+func Test_WriteXLSX(t *testing.T) {
+	response := getTestResponse(t)
+	var buffer bytes.Buffer
+	if err := WriteXLSX(&buffer, response, Options{Language: LanguageEnglish}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if sheets := f.GetSheetList(); len(sheets) != 2 || sheets[0] != "Summary" || sheets[1] != "Schedule" {
+		t.Fatalf("unexpected sheets %v", sheets)
+	}
+	rows, err := f.GetRows("Schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1行表头 12期 1行合计
+	if len(rows) != 14 || rows[0][0] != "Period" || rows[13][0] != "Total" {
+		t.Fatalf("unexpected schedule rows %v", rows)
+	}
+	// 合计行的利息等于总利息
+	value, err := f.GetCellValue("Schedule", "H14", excelize.Options{RawCellValue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interest, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decimal.NewFromFloat(interest).Equal(response.TotalInterest) {
+		t.Errorf("got total interest %s, want %s", value, response.TotalInterest)
+	}
+	summary, err := f.GetRows("Summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary) != len(summaryItems) || summary[7][0] != "Total Interest" {
+		t.Errorf("unexpected summary %v", summary)
+	}
+}
+
+func Test_WritePDF(t *testing.T) {
+	response := getTestResponse(t)
+	// 360期需要分页
+	longResponse, err := plan.CalculateRepaymentPlan(&plan.Request{
+		LoanAmount:    decimal.NewFromFloat(400000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(4.9),
+		PeriodNum:     360,
+		RepayDay:      1,
+		LoanCycleCode: plan.LoanCycleMonthly,
+		RepayMethod:   plan.EqualLoanRepayment,
+		PeriodType:    plan.PeriodTypeMonth,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := PDFOptions{Options: Options{Language: LanguageEnglish}, Font: goregular.TTF}
+	cases := []struct {
+		response *plan.Response
+		pages    int
+	}{
+		{response, 1},
+		{longResponse, 13},
+	}
+	for _, c := range cases {
+		var buffer bytes.Buffer
+		if err := WritePDF(&buffer, c.response, options); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(buffer.Bytes(), []byte("%PDF-")) {
+			t.Fatalf("not a pdf")
+		}
+		if pages := len(regexp.MustCompile(`/Type /Page\b[^s]`).FindAll(buffer.Bytes(), -1)); pages != c.pages {
+			t.Errorf("got %d pages, want %d", pages, c.pages)
+		}
+	}
+
+	if err := WritePDF(&bytes.Buffer{}, response, PDFOptions{}); err == nil {
+		t.Errorf("want font error")
+	}
+	// 中文需要包含中文字形的字体,goregular 只有西文字形
+	err = WritePDF(&bytes.Buffer{}, response, PDFOptions{Options: Options{Language: LanguageChinese}, Font: goregular.TTF})
+	if err == nil || !strings.Contains(err.Error(), "no glyph") {
+		t.Errorf("got %v, want font glyph error", err)
+	}
+	if err = WritePDF(&bytes.Buffer{}, response, PDFOptions{Options: options.Options, Font: []byte("not a font")}); err == nil {
+		t.Errorf("want font format error")
+	}
+}
+
+func Test_WriteICS(t *testing.T) {
+	response := getTestResponse(t)
+	var buffer bytes.Buffer
+	timestamp := time.Date(2022, 1, 1, 8, 0, 0, 0, time.UTC)
+	err := WriteICS(&buffer, response, ICSOptions{LoanID: "L001", ReminderDays: 3, Timestamp: timestamp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ics := buffer.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"UID:L001-1@repaymentplan\r\nDTSTAMP:20220101T080000Z\r\nDTSTART;VALUE=DATE:20220201\r\nDTEND;VALUE=DATE:20220202\r\n",
+		"SUMMARY:第1期还款 " + response.PlanRepayRecords[0].PeriodRepayTotalAmount.StringFixed(2) + " CNY\r\n",
+		"UID:L001-12@repaymentplan\r\n",
+		"TRIGGER:-P3D\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("missing %q in\n%s", want, ics)
+		}
+	}
+	if events := strings.Count(ics, "BEGIN:VEVENT"); events != 12 {
+		t.Errorf("got %d events, want 12", events)
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	if err = WriteICS(&bytes.Buffer{}, response, ICSOptions{}); err == nil {
+		t.Errorf("want loanId error")
+	}
+}
+
+func Test_foldICSLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("还款", 30)
+	folded := foldICSLine(line)
+	if strings.ReplaceAll(folded, "\r\n ", "") != line+"\r\n" {
+		t.Errorf("unfold %q", folded)
+	}
+	for _, l := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		if len(l) > 75 || !utf8.ValidString(l) {
+			t.Errorf("bad folded line %q", l)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"github.com/linjinrongbb/repaymentPlan/plan"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	icsProductID  = "-//linjinrongbb//repaymentPlan//CN"
+	icsUIDDomain  = "repaymentplan"
+	icsDateFormat = "20060102"
+	icsTimeFormat = "20060102T150405Z"
+	// 内容行最长75字节,超出时折行
+	icsMaxLineOctets = 75
+)
+
+// ICSOptions 日历导出选项
+type ICSOptions struct {
+	Options
+	LoanID       string    // 贷款编号 必填,与期次一起生成事件的 UID,重复导出时日历软件据此更新而不是新增事件
+	ReminderDays int       // 提前几天提醒 0为不提醒
+	Timestamp    time.Time // 事件的 DTSTAMP 默认为当前时间
+}
+
+/**
+  *@Description 把还款计划写为 RFC 5545 的 iCalendar：每一期的还款日为一个全天事件，标题为本期还款总金额，可设置提前提醒
+**/
+func WriteICS(w io.Writer, response *plan.Response, options ICSOptions) error {
+	if options.LoanID == "" {
+		return errors.New("loanId error")
+	}
+	if options.ReminderDays < 0 {
+		return errors.New("reminderDays error")
+	}
+	timestamp := options.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	currency := response.RoundingPolicy.Currency
+	scale := int32(getAmountScale(response))
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + icsProductID,
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escapeICSText(options.translate("还款计划 ", "Repayment Plan ")+options.LoanID),
+	}
+	for _, record := range response.PlanRepayRecords {
+		repayDate, err := time.Parse(plan.DATE_DASH_FORMAT, record.PeriodRepayDate)
+		if err != nil {
+			return err
+		}
+		amount := strings.TrimSpace(record.PeriodRepayTotalAmount.StringFixed(scale) + " " + currency)
+		summary := options.translate(fmt.Sprintf("第%d期还款 %s", record.PeriodNum, amount),
+			fmt.Sprintf("Repayment %d: %s", record.PeriodNum, amount))
+		description := options.translate(
+			fmt.Sprintf("本金 %s\n利息 %s\n剩余本金 %s", record.PeriodRepayPrinciple.StringFixed(scale),
+				record.PeriodRepayInterest.StringFixed(scale), record.MaintainPrinciple.StringFixed(scale)),
+			fmt.Sprintf("Principal %s\nInterest %s\nOutstanding principal %s", record.PeriodRepayPrinciple.StringFixed(scale),
+				record.PeriodRepayInterest.StringFixed(scale), record.MaintainPrinciple.StringFixed(scale)))
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:%s-%d@%s", escapeICSText(options.LoanID), record.PeriodNum, icsUIDDomain),
+			"DTSTAMP:"+timestamp.UTC().Format(icsTimeFormat),
+			"DTSTART;VALUE=DATE:"+repayDate.Format(icsDateFormat),
+			"DTEND;VALUE=DATE:"+repayDate.AddDate(0, 0, 1).Format(icsDateFormat),
+			"SUMMARY:"+escapeICSText(summary),
+			"DESCRIPTION:"+escapeICSText(description),
+			"TRANSP:TRANSPARENT",
+		)
+		if options.ReminderDays > 0 {
+			lines = append(lines,
+				"BEGIN:VALARM",
+				"ACTION:DISPLAY",
+				fmt.Sprintf("TRIGGER:-P%dD", options.ReminderDays),
+				"DESCRIPTION:"+escapeICSText(summary),
+				"END:VALARM",
+			)
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(foldICSLine(line))
+	}
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// 文本值中的反斜杠、分号、逗号和换行需要转义
+func escapeICSText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// 超过75字节的内容行折行,续行以空格开头,不拆分多字节字符;行以 CRLF 结尾
+func foldICSLine(line string) string {
+	var builder strings.Builder
+	octets := 0
+	for _, r := range line {
+		size := len(string(r))
+		if octets+size > icsMaxLineOctets {
+			builder.WriteString("\r\n ")
+			// 续行开头的空格也计入长度
+			octets = 1
+		}
+		builder.WriteRune(r)
+		octets += size
+	}
+	builder.WriteString("\r\n")
+	return builder.String()
+}
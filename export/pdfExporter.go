@@ -0,0 +1,213 @@
+package export
+
+import (
+	"errors"
+	"github.com/go-pdf/fpdf"
+	"github.com/linjinrongbb/repaymentPlan/plan"
+	"github.com/shopspring/decimal"
+	"golang.org/x/image/font/sfnt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// pdf 排版参数,单位毫米
+const (
+	pdfFontFamily    = "cjk"
+	pdfMargin        = 10.0
+	pdfTitleSize     = 16.0
+	pdfFontSize      = 8.0
+	pdfRowHeight     = 6.0
+	pdfCellPadding   = 2.0
+	pdfSummaryColumn = 2 // 汇总每行的项数
+)
+
+// PDFOptions PDF 导出选项
+type PDFOptions struct {
+	Options
+	Font []byte // TrueType 字体(ttf),中文需包含中文字形,如思源黑体、文泉驿;不支持 ttc 和 CFF 格式的 otf
+}
+
+// LoadFont 读取 TrueType 字体文件
+func LoadFont(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+/**
+  *@Description 把还款计划写为 A4 横向的 PDF：标题和贷款概要，之后为各期明细和合计行，换页时重复表头，页脚为页码
+**/
+func WritePDF(w io.Writer, response *plan.Response, options PDFOptions) error {
+	if len(options.Font) == 0 {
+		return errors.New("font error")
+	}
+	if err := checkPDFFont(options.Font, options.Options); err != nil {
+		return err
+	}
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	// 换页由表格自行处理,以便重复表头
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.AddUTF8FontFromBytes(pdfFontFamily, "", options.Font)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin)
+		pdf.SetFont(pdfFontFamily, "", pdfFontSize)
+		pdf.CellFormat(0, pdfRowHeight/2, options.translate("第 "+strconv.Itoa(pdf.PageNo())+" 页 / 共 {nb} 页",
+			"Page "+strconv.Itoa(pdf.PageNo())+" of {nb}"), "", 0, "C", false, 0, "")
+	})
+	pdf.SetFillColor(230, 230, 230)
+	pdf.AddPage()
+	if pdf.Err() {
+		return pdf.Error()
+	}
+
+	pdf.SetFont(pdfFontFamily, "", pdfTitleSize)
+	pdf.CellFormat(0, pdfRowHeight*2, options.translate("还款计划表", "Repayment Schedule"), "", 1, "C", false, 0, "")
+	pdf.SetFont(pdfFontFamily, "", pdfFontSize)
+	writePDFSummary(pdf, response, options.Options)
+	pdf.Ln(pdfRowHeight)
+	writePDFSchedule(pdf, response, options.Options)
+	return pdf.Output(w)
+}
+
+// 字体需包含标题、表头和概要项目的字形,否则 PDF 中这些文字显示为空白
+func checkPDFFont(font []byte, options Options) error {
+	f, err := sfnt.Parse(font)
+	if err != nil {
+		return errors.New("font error: " + err.Error())
+	}
+	texts := append([]string{options.translate("还款计划表", "Repayment Schedule")}, getHeaders(options)...)
+	for _, item := range summaryItems {
+		texts = append(texts, options.translate(item.zh, item.en))
+	}
+	var buffer sfnt.Buffer
+	for _, text := range texts {
+		for _, r := range text {
+			if index, err := f.GlyphIndex(&buffer, r); err != nil || index == 0 {
+				return errors.New("font error: font has no glyph for " + strconv.QuoteRune(r) + ", use a font that contains the glyphs of the language")
+			}
+		}
+	}
+	return nil
+}
+
+// 贷款概要,每行两项
+func writePDFSummary(pdf *fpdf.Fpdf, response *plan.Response, options Options) {
+	pageWidth, _ := pdf.GetPageSize()
+	width := (pageWidth - 2*pdfMargin) / pdfSummaryColumn / 2
+	scale := int32(getAmountScale(response))
+	for i, item := range summaryItems {
+		value := item.value(response, options)
+		text := ""
+		switch v := value.(type) {
+		case decimal.Decimal:
+			if item.isRate {
+				text = v.String()
+			} else {
+				text = v.StringFixed(scale)
+			}
+		case int:
+			text = strconv.Itoa(v)
+		case string:
+			text = v
+		}
+		ln := 0
+		if (i+1)%pdfSummaryColumn == 0 || i == len(summaryItems)-1 {
+			ln = 1
+		}
+		pdf.CellFormat(width, pdfRowHeight, options.translate(item.zh, item.en), "1", 0, "L", true, 0, "")
+		pdf.CellFormat(width, pdfRowHeight, text, "1", ln, "R", false, 0, "")
+	}
+}
+
+// 各期明细:列宽按内容计算后缩放到页宽,每页重复表头,最后为合计行
+func writePDFSchedule(pdf *fpdf.Fpdf, response *plan.Response, options Options) {
+	scale := int32(getAmountScale(response))
+	headers := getHeaders(options)
+	rows := make([][]string, 0, len(response.PlanRepayRecords)+1)
+	for _, record := range response.PlanRepayRecords {
+		rows = append(rows, getPDFRecordRow(record, options, scale))
+	}
+	totalRow := make([]string, len(planColumns))
+	totalRow[0] = options.translate("合计", "Total")
+	for col, total := range getTotals(response) {
+		if planColumns[col].isTotal {
+			totalRow[col] = total.StringFixed(scale)
+		}
+	}
+	rows = append(rows, totalRow)
+	widths := getPDFColumnWidths(pdf, headers, rows)
+
+	_, pageHeight := pdf.GetPageSize()
+	// 表格的下边界,留出页脚
+	bottom := pageHeight - pdfMargin - pdfRowHeight
+	writeHeader := func() {
+		for col, header := range headers {
+			pdf.CellFormat(widths[col], pdfRowHeight, header, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	writeHeader()
+	for _, row := range rows {
+		if pdf.GetY()+pdfRowHeight > bottom {
+			pdf.AddPage()
+			writeHeader()
+		}
+		for col, cell := range row {
+			align := "R"
+			if !planColumns[col].isDecimal && !planColumns[col].isInt {
+				align = "C"
+			}
+			pdf.CellFormat(widths[col], pdfRowHeight, cell, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+// 列宽取表头和各行内容的最大宽度,再按比例缩放到页宽
+func getPDFColumnWidths(pdf *fpdf.Fpdf, headers []string, rows [][]string) []float64 {
+	widths := make([]float64, len(headers))
+	for col, header := range headers {
+		widths[col] = pdf.GetStringWidth(header)
+	}
+	for _, row := range rows {
+		for col, cell := range row {
+			if width := pdf.GetStringWidth(cell); width > widths[col] {
+				widths[col] = width
+			}
+		}
+	}
+	total := 0.0
+	for col := range widths {
+		widths[col] += 2 * pdfCellPadding
+		total += widths[col]
+	}
+	pageWidth, _ := pdf.GetPageSize()
+	ratio := (pageWidth - 2*pdfMargin) / total
+	for col := range widths {
+		widths[col] *= ratio
+	}
+	return widths
+}
+
+// 金额按舍入规则的小数位数显示,是否类的列显示为是/否
+func getPDFRecordRow(record plan.RepayPlanRecord, options Options, scale int32) []string {
+	row := make([]string, 0, len(planColumns))
+	for _, c := range planColumns {
+		switch {
+		case c.isBool:
+			if c.value(record) == strconv.FormatBool(true) {
+				row = append(row, options.translate("是", "Yes"))
+			} else {
+				row = append(row, options.translate("否", "No"))
+			}
+		case c.isRate:
+			row = append(row, c.decimal(record).String())
+		case c.isDecimal:
+			row = append(row, c.decimal(record).StringFixed(scale))
+		default:
+			row = append(row, c.value(record))
+		}
+	}
+	return row
+}
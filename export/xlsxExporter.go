@@ -0,0 +1,174 @@
+package export
+
+import (
+	"github.com/linjinrongbb/repaymentPlan/plan"
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// 利率的数字格式
+	rateNumFmt = "0.00##"
+)
+
+// xlsx 写入时的样式
+type xlsxStyles struct {
+	header int
+	amount int
+	rate   int
+}
+
+/**
+  *@Description 把还款计划写为 XLSX：汇总页为贷款概要、总利息和总还款金额，还款计划页为各期明细和合计行，金额按数字格式写入
+**/
+func WriteXLSX(w io.Writer, response *plan.Response, options Options) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	styles, err := newXLSXStyles(f, response)
+	if err != nil {
+		return err
+	}
+	summarySheet := options.translate("汇总", "Summary")
+	if err = f.SetSheetName(f.GetSheetName(0), summarySheet); err != nil {
+		return err
+	}
+	if err = writeSummarySheet(f, summarySheet, response, options, styles); err != nil {
+		return err
+	}
+	scheduleSheet := options.translate("还款计划", "Schedule")
+	if _, err = f.NewSheet(scheduleSheet); err != nil {
+		return err
+	}
+	if err = writeScheduleSheet(f, scheduleSheet, response, options, styles); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+func newXLSXStyles(f *excelize.File, response *plan.Response) (xlsxStyles, error) {
+	var styles xlsxStyles
+	var err error
+	if styles.header, err = f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}); err != nil {
+		return styles, err
+	}
+	// 金额按舍入规则的小数位数显示
+	scale := getAmountScale(response)
+	amountNumFmt := "#,##0"
+	if scale > 0 {
+		amountNumFmt += "." + strings.Repeat("0", scale)
+	}
+	if styles.amount, err = f.NewStyle(&excelize.Style{CustomNumFmt: &amountNumFmt}); err != nil {
+		return styles, err
+	}
+	rateFmt := rateNumFmt
+	styles.rate, err = f.NewStyle(&excelize.Style{CustomNumFmt: &rateFmt})
+	return styles, err
+}
+
+func writeSummarySheet(f *excelize.File, sheet string, response *plan.Response, options Options, styles xlsxStyles) error {
+	for i, item := range summaryItems {
+		labelCell, _ := excelize.CoordinatesToCellName(1, i+1)
+		valueCell, _ := excelize.CoordinatesToCellName(2, i+1)
+		if err := f.SetCellStr(sheet, labelCell, options.translate(item.zh, item.en)); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheet, labelCell, labelCell, styles.header); err != nil {
+			return err
+		}
+		value := item.value(response, options)
+		if d, ok := value.(decimal.Decimal); ok {
+			style := styles.amount
+			if item.isRate {
+				style = styles.rate
+			}
+			if err := setDecimalCell(f, sheet, valueCell, d, style); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := f.SetCellValue(sheet, valueCell, value); err != nil {
+			return err
+		}
+	}
+	return f.SetColWidth(sheet, "A", "B", 20)
+}
+
+func writeScheduleSheet(f *excelize.File, sheet string, response *plan.Response, options Options, styles xlsxStyles) error {
+	for col, header := range getHeaders(options) {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+		if err := f.SetCellStr(sheet, cell, header); err != nil {
+			return err
+		}
+	}
+	lastCell, _ := excelize.CoordinatesToCellName(len(planColumns), 1)
+	if err := f.SetCellStyle(sheet, "A1", lastCell, styles.header); err != nil {
+		return err
+	}
+
+	for i, record := range response.PlanRepayRecords {
+		for col, c := range planColumns {
+			cell, _ := excelize.CoordinatesToCellName(col+1, i+2)
+			if !c.isDecimal {
+				if err := setTextCell(f, sheet, cell, c, record); err != nil {
+					return err
+				}
+				continue
+			}
+			value := c.decimal(record)
+			style := styles.amount
+			if c.isRate {
+				style = styles.rate
+			}
+			if err := setDecimalCell(f, sheet, cell, value, style); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 合计行
+	totalRow := len(response.PlanRepayRecords) + 2
+	cell, _ := excelize.CoordinatesToCellName(1, totalRow)
+	if err := f.SetCellStr(sheet, cell, options.translate("合计", "Total")); err != nil {
+		return err
+	}
+	totals := getTotals(response)
+	for col, c := range planColumns {
+		if !c.isTotal {
+			continue
+		}
+		cell, _ = excelize.CoordinatesToCellName(col+1, totalRow)
+		if err := setDecimalCell(f, sheet, cell, totals[col], styles.amount); err != nil {
+			return err
+		}
+	}
+	cell, _ = excelize.CoordinatesToCellName(1, totalRow)
+	if err := f.SetCellStyle(sheet, cell, cell, styles.header); err != nil {
+		return err
+	}
+	lastColumn, _ := excelize.ColumnNumberToName(len(planColumns))
+	return f.SetColWidth(sheet, "A", lastColumn, 14)
+}
+
+// 期次、天数按整数写入,其他按文本写入
+func setTextCell(f *excelize.File, sheet, cell string, c column, record plan.RepayPlanRecord) error {
+	value := c.value(record)
+	if c.isInt {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		return f.SetCellInt(sheet, cell, number)
+	}
+	return f.SetCellStr(sheet, cell, value)
+}
+
+func setDecimalCell(f *excelize.File, sheet, cell string, value decimal.Decimal, style int) error {
+	if err := f.SetCellFloat(sheet, cell, value.InexactFloat64(), -1, 64); err != nil {
+		return err
+	}
+	return f.SetCellStyle(sheet, cell, cell, style)
+}
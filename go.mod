@@ -0,0 +1,21 @@
+module github.com/linjinrongbb/repaymentPlan
+
+go 1.21
+
+require (
+	github.com/go-pdf/fpdf v0.9.0
+	github.com/shopspring/decimal v1.4.0
+	github.com/xuri/excelize/v2 v2.9.0
+	golang.org/x/image v0.18.0
+)
+
+require (
+	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.4 // indirect
+	github.com/xuri/efp v0.0.0-20240408161823-9ad904a10d6d // indirect
+	github.com/xuri/nfp v0.0.0-20240318013403-ab9948c2c4a7 // indirect
+	golang.org/x/crypto v0.28.0 // indirect
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+)
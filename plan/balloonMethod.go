@@ -0,0 +1,73 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"math"
+	"strconv"
+)
+
+/**
+  *@Description 气球贷(尾款贷)：尾款在最后一期归还，其余本金按等额本息摊还，每期还款金额按扣除尾款现值后的本金计算
+**/
+func balloonMethodPlan(request repayPlanRequest, response *Response) error {
+	everyPeriodRepayAmount, err := calculateBalloonInstallmentMethod(request.LoanAmount, request.BalloonAmount, request.PeriodInterestRate,
+		request.TotalPeriodNum, request.RoundingPolicy)
+	if err != nil {
+		return err
+	}
+
+	records, err := calculateFixedInstallmentPlanRecords(request, request.PeriodDates, everyPeriodRepayAmount)
+	if err != nil {
+		return err
+	}
+	// 最后一期标明尾款
+	records[len(records)-1].BalloonAmount = request.BalloonAmount
+	fillRepayPlanRecords(response, records)
+	response.BalloonAmount = request.BalloonAmount
+
+	return nil
+}
+
+func checkBalloon(request *Request) error {
+	if request.RepayMethod != BalloonRepayment {
+		if request.BalloonAmount.IsZero() && request.BalloonPercent.IsZero() {
+			return nil
+		}
+		return newValidationError("balloonAmount", CodeUnsupported, "repay method error: balloon amount only supported by balloon repayment")
+	}
+	var errs ValidationErrors
+	if request.BalloonAmount.IsZero() == request.BalloonPercent.IsZero() {
+		errs.add("balloonAmount", CodeConflict, "balloon Amount and balloon Percent must be set one of them")
+	}
+	if request.BalloonAmount.IsNegative() || request.BalloonAmount.GreaterThanOrEqual(request.LoanAmount) {
+		errs.add("balloonAmount", CodeInvalid, "balloon Amount error")
+	}
+	if request.BalloonPercent.IsNegative() || request.BalloonPercent.GreaterThanOrEqual(decimal.NewFromInt(100)) {
+		errs.add("balloonPercent", CodeInvalid, "balloon Percent error")
+	}
+	return errs.err()
+}
+
+// 尾款金额:按比例设置时=贷款金额*尾款比例
+func getBalloonAmount(request *Request) decimal.Decimal {
+	if request.BalloonPercent.IsZero() {
+		return request.BalloonAmount
+	}
+	return roundAmount(request.LoanAmount.Mul(request.BalloonPercent).Div(decimal.NewFromInt(100)), request.RoundingPolicy)
+}
+
+// 扣除尾款现值后按等额本息计算每期还款金额,尾款为0时与等额本息相同
+func calculateBalloonInstallmentMethod(loanAmount, balloonAmount, periodInterestRate decimal.Decimal, totalPeriodNum int,
+	roundingPolicy RoundingPolicy) (decimal.Decimal, error) {
+	if balloonAmount.IsZero() {
+		return calculateFixedInstallmentMethod(loanAmount, periodInterestRate, totalPeriodNum, roundingPolicy)
+	}
+	// 尾款现值=尾款/(1+期利率)^期数
+	periodRateCal, err := strconv.ParseFloat(periodInterestRate.Add(decimal.NewFromInt(1)).String(), 64)
+	if err != nil {
+		return decimal.Zero, errors.New("int to float error:" + err.Error())
+	}
+	presentValue := balloonAmount.Div(decimal.NewFromFloat(math.Pow(periodRateCal, float64(totalPeriodNum))))
+	return calculateFixedInstallmentMethod(loanAmount.Sub(presentValue), periodInterestRate, totalPeriodNum, roundingPolicy)
+}
@@ -0,0 +1,52 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+/**
+  *@Description 气球贷 尾款30% 最后一期归还
+**/
+func Test_balloonMethod(t *testing.T) {
+	request := &Request{
+		LoanAmount:     decimal.NewFromFloat(100000),
+		LoanStartDate:  "2022-01-01",
+		InterestRate:   decimal.NewFromFloat(6),
+		PeriodNum:      12,
+		RepayDay:       1,
+		LoanCycleCode:  LoanCycleMonthly,
+		RepayMethod:    BalloonRepayment,
+		PeriodType:     PeriodTypeMonth,
+		BalloonPercent: decimal.NewFromInt(30),
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每期还款金额=(100000-30000/1.005^12)*0.005*1.005^12/(1.005^12-1)
+	everyPeriodRepayAmount := decimal.RequireFromString("6174.65")
+	records := resp.PlanRepayRecords
+	if !records[0].PeriodRepayTotalAmount.Equal(everyPeriodRepayAmount) || !resp.BalloonAmount.Equal(decimal.NewFromInt(30000)) {
+		t.Errorf("got installment %s balloon %s", records[0].PeriodRepayTotalAmount, resp.BalloonAmount)
+	}
+	last := records[len(records)-1]
+	// 最后一期=每期还款金额+尾款,按实际天数计息产生的尾差也在最后一期
+	if !last.BalloonAmount.Equal(decimal.NewFromInt(30000)) || !last.MaintainPrinciple.IsZero() ||
+		!last.PeriodRepayTotalAmount.Equal(decimal.RequireFromString("36223.38")) {
+		t.Errorf("unexpected last period %+v", last)
+	}
+	if !records[10].MaintainPrinciple.Sub(decimal.NewFromInt(30000)).Abs().LessThan(everyPeriodRepayAmount) {
+		t.Errorf("maintain principle before last period %s", records[10].MaintainPrinciple)
+	}
+
+	request.BalloonAmount = decimal.NewFromInt(30000)
+	if _, err = CalculateRepaymentPlan(request); err == nil {
+		t.Error("expected error for both balloon amount and percent")
+	}
+	request.BalloonPercent = decimal.Zero
+	request.RepayMethod = EqualLoanRepayment
+	if _, err = CalculateRepaymentPlan(request); err == nil {
+		t.Error("expected error for balloon amount of equal loan repayment")
+	}
+}
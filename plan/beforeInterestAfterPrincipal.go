@@ -1,4 +1,4 @@
-package main
+package plan
 
 import (
 	"github.com/shopspring/decimal"
@@ -13,7 +13,7 @@ func beforeInterestAfterPrincipalPlan(request repayPlanRequest, response *Respon
 	var sumTotalInterest, sumTotalRepayAmount decimal.Decimal
 
 	records := make([]RepayPlanRecord, 0)
-	dateMap := calculatePeriodDate(request)
+	dateMap := request.PeriodDates
 
 	// 2.make repay plan
 	for i := 0; i < request.TotalPeriodNum; i++ {
@@ -21,11 +21,9 @@ func beforeInterestAfterPrincipalPlan(request repayPlanRequest, response *Respon
 		periodEndDate := dateMap[i][1]
 		periodRepayDate := dateMap[i][2]
 
-		// 当前期次的计息天数
-		daysOfPeriod := getDaysBetweenDate(periodStartDate, periodEndDate)
-
-		// 当前期次的利息金额=贷款本金*计息天数*日利息
-		periodRepayInterest := request.LoanAmount.Mul(request.DaysInterestRate).Mul(decimal.NewFromInt(daysOfPeriod)).Round(2)
+		// 当前期次的利息金额=贷款本金*计息天数*日利息,计息天数和日利息按计息基准计算
+		interest, daysOfPeriod := calculatePeriodInterest(request, request.LoanAmount, periodStartDate, periodEndDate.AddDate(0, 0, 1))
+		periodRepayInterest := roundAmount(interest, request.RoundingPolicy)
 
 		record := RepayPlanRecord{
 			PeriodNum:           i + 1,
@@ -34,6 +32,8 @@ func beforeInterestAfterPrincipalPlan(request repayPlanRequest, response *Respon
 			PeriodRepayDate:     periodRepayDate.Format(DATE_DASH_FORMAT), // 当前期次的还款日
 			DaysOfPeriod:        int(daysOfPeriod),                        // 当前期次的计息天数
 			PeriodRepayInterest: periodRepayInterest,                      // 当前期次的利息
+			DayCountConvention:  request.DayCountConvention,
+			InterestRate:        getInterestRateOf(request, periodEndDate), // 当前期次的年利率
 		}
 
 		if i == request.TotalPeriodNum-1 {
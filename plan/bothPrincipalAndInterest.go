@@ -1,4 +1,4 @@
-package main
+package plan
 
 import (
 	"github.com/shopspring/decimal"
@@ -14,40 +14,66 @@ func bothPrincipalAndInterest(request *Request) (response *Response, err error)
 	loanStartDateParseLocal, _ := time.ParseInLocation(DATE_DASH_FORMAT, request.LoanStartDate, time.Local)
 	loanEndDateParseLocal, _ := time.ParseInLocation(DATE_DASH_FORMAT, request.LoanEndDate, time.Local)
 
-	// 1.Daily interest rate 日利率
-	daysInterestRate := calculateDaysInterestRate(request.InterestRate, request.DaysOfYear)
-
-	reCalEndDate := loanEndDateParseLocal.AddDate(0, 0, -1)
+	rateSchedule, err := getRateSchedule(request, loanStartDateParseLocal, loanEndDateParseLocal)
+	if err != nil {
+		return nil, err
+	}
+	rateCurve, err := parseRateCurve(rateSchedule)
+	if err != nil {
+		return nil, err
+	}
+	planRequest := repayPlanRequest{
+		InterestRate:          request.InterestRate,
+		DaysOfYear:            request.DaysOfYear,
+		DayCountConvention:    request.DayCountConvention,
+		RateCurve:             rateCurve,
+		Calendar:              request.Calendar,
+		BusinessDayConvention: request.BusinessDayConvention,
+		AdjustInterestEndDate: request.AdjustInterestEndDate,
+	}
 
-	// 2.get this period's interest begin 计息天数
-	daysOfPeriod := getDaysBetweenDate(loanStartDateParseLocal, reCalEndDate)
+	// 到期日遇节假日调整
+	dateMap := map[int][]time.Time{0: {loanStartDateParseLocal, loanEndDateParseLocal.AddDate(0, 0, -1), loanEndDateParseLocal}}
+	if err = adjustPeriodDate(planRequest, dateMap); err != nil {
+		return nil, err
+	}
+	reCalEndDate, periodRepayDate := dateMap[0][1], dateMap[0][2]
 
-	// 3.calculate total interest amount
-	totalInterest := request.LoanAmount.Mul(daysInterestRate).Mul(decimal.NewFromInt(daysOfPeriod)).Round(2)
+	// 1.calculate total interest amount and days 按计息基准计算利息和计息天数,期内利率调整时分段计息
+	totalInterest, daysOfPeriod := calculatePeriodInterest(planRequest, request.LoanAmount, loanStartDateParseLocal, reCalEndDate.AddDate(0, 0, 1))
+	totalInterest = roundAmount(totalInterest, request.RoundingPolicy)
 
-	// 4.response only one period's plan
+	// 2.response only one period's plan
 	totalAmount := totalInterest.Add(request.LoanAmount)
 	record := RepayPlanRecord{
 		PeriodNum:              1,
 		PeriodStartDate:        request.LoanStartDate,
 		PeriodEndDate:          reCalEndDate.Format(DATE_DASH_FORMAT),
-		PeriodRepayDate:        request.LoanEndDate,
+		PeriodRepayDate:        periodRepayDate.Format(DATE_DASH_FORMAT),
 		PeriodRepayPrinciple:   request.LoanAmount,
 		MaintainPrinciple:      decimal.NewFromFloat(0),
 		DaysOfPeriod:           int(daysOfPeriod),
 		PeriodRepayInterest:    totalInterest,
 		PeriodRepayTotalAmount: totalAmount,
+		DayCountConvention:     request.DayCountConvention,
+		InterestRate:           getInterestRateOf(planRequest, reCalEndDate),
 	}
 	response = &Response{
-		TotalPeriodNum:   1,
-		RepayMethod:      request.RepayMethod,
-		LoanStartDate:    request.LoanStartDate,
-		LoanEndDate:      request.LoanEndDate,
-		LoanAmount:       request.LoanAmount,
-		InterestRate:     request.InterestRate,
-		TotalRepayAmount: totalAmount,
-		TotalInterest:    totalInterest,
-		PlanRepayRecords: []RepayPlanRecord{record},
+		TotalPeriodNum:        1,
+		RepayMethod:           request.RepayMethod,
+		LoanStartDate:         request.LoanStartDate,
+		LoanEndDate:           request.LoanEndDate,
+		LoanAmount:            request.LoanAmount,
+		InterestRate:          request.InterestRate,
+		TotalRepayAmount:      totalAmount,
+		TotalInterest:         totalInterest,
+		PlanRepayRecords:      []RepayPlanRecord{record},
+		LoanCycleCode:         request.LoanCycleCode,
+		DaysOfYear:            request.DaysOfYear,
+		DayCountConvention:    request.DayCountConvention,
+		RoundingPolicy:        request.RoundingPolicy,
+		RateSchedule:          rateSchedule,
+		BusinessDayConvention: request.BusinessDayConvention,
 	}
 	return response, nil
 }
@@ -0,0 +1,197 @@
+package plan
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HolidayCalendar 节假日日历:判断某日是否为工作日
+type HolidayCalendar interface {
+	IsBusinessDay(date time.Time) bool
+}
+
+// BusinessDayConvention 还款日遇节假日的调整方式
+type BusinessDayConvention string
+
+// 还款日调整方式
+const (
+	BusinessDayUnadjusted        BusinessDayConvention = "unadjusted"         // 不调整
+	BusinessDayFollowing         BusinessDayConvention = "following"          // 顺延至下一个工作日
+	BusinessDayModifiedFollowing BusinessDayConvention = "modified-following" // 顺延至下一个工作日,跨月时提前至上一个工作日
+	BusinessDayPreceding         BusinessDayConvention = "preceding"          // 提前至上一个工作日
+)
+
+// 节假日文件中补班日的标记
+const holidayFileWorkdayTag = "workday"
+
+// 查找工作日的最大天数,超过则认为节假日日历有误
+const maxBusinessDaySearchDays = 366
+
+// MemoryHolidayCalendar 内存节假日日历:周六、周日和节假日为非工作日,补班日为工作日
+type MemoryHolidayCalendar struct {
+	holidays map[string]bool // 节假日
+	workdays map[string]bool // 周末补班日
+}
+
+// NewHolidayCalendar 根据节假日日期(yyyy-MM-dd)创建节假日日历
+func NewHolidayCalendar(holidays ...string) (*MemoryHolidayCalendar, error) {
+	calendar := &MemoryHolidayCalendar{holidays: make(map[string]bool), workdays: make(map[string]bool)}
+	for _, holiday := range holidays {
+		if err := calendar.AddHoliday(holiday); err != nil {
+			return nil, err
+		}
+	}
+	return calendar, nil
+}
+
+/**
+  *@Description 从文件加载节假日日历:每行一个日期(yyyy-MM-dd),日期后跟 workday 表示周末补班,# 开头为注释
+**/
+func LoadHolidayCalendar(path string) (*MemoryHolidayCalendar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("holiday calendar file error: " + err.Error())
+	}
+	defer file.Close()
+	return ParseHolidayCalendar(file)
+}
+
+// ParseHolidayCalendar 按节假日文件格式读取节假日日历
+func ParseHolidayCalendar(reader io.Reader) (*MemoryHolidayCalendar, error) {
+	calendar, _ := NewHolidayCalendar()
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		var err error
+		switch {
+		case len(fields) == 1:
+			err = calendar.AddHoliday(fields[0])
+		case len(fields) == 2 && fields[1] == holidayFileWorkdayTag:
+			err = calendar.AddWorkday(fields[0])
+		default:
+			err = errors.New("holiday calendar line error: " + line)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("holiday calendar file error: " + err.Error())
+	}
+	return calendar, nil
+}
+
+// AddHoliday 添加节假日
+func (c *MemoryHolidayCalendar) AddHoliday(date string) error {
+	if _, err := time.ParseInLocation(DATE_DASH_FORMAT, date, time.Local); err != nil {
+		return errors.New("holiday Date error: " + date)
+	}
+	c.holidays[date] = true
+	delete(c.workdays, date)
+	return nil
+}
+
+// AddWorkday 添加周末补班日
+func (c *MemoryHolidayCalendar) AddWorkday(date string) error {
+	if _, err := time.ParseInLocation(DATE_DASH_FORMAT, date, time.Local); err != nil {
+		return errors.New("workday Date error: " + date)
+	}
+	c.workdays[date] = true
+	delete(c.holidays, date)
+	return nil
+}
+
+// IsBusinessDay 是否为工作日
+func (c *MemoryHolidayCalendar) IsBusinessDay(date time.Time) bool {
+	key := date.Format(DATE_DASH_FORMAT)
+	if c.workdays[key] {
+		return true
+	}
+	if c.holidays[key] {
+		return false
+	}
+	return date.Weekday() != time.Saturday && date.Weekday() != time.Sunday
+}
+
+func checkBusinessDayConvention(request *Request) error {
+	switch request.BusinessDayConvention {
+	case "":
+		request.BusinessDayConvention = BusinessDayUnadjusted
+	case BusinessDayUnadjusted:
+	case BusinessDayFollowing, BusinessDayModifiedFollowing, BusinessDayPreceding:
+		if request.Calendar == nil {
+			return newValidationError("calendar", CodeRequired, "holiday Calendar can not be empty")
+		}
+		// 按日还款每天都是还款日,调整后会出现重复的还款日
+		if request.LoanCycleCode == LoanCycleDaily {
+			return newValidationError("businessDayConvention", CodeUnsupported, "business Day Convention error: daily loan cycle can not be adjusted")
+		}
+	default:
+		return newValidationError("businessDayConvention", CodeInvalid, "business Day Convention error")
+	}
+	return nil
+}
+
+// 按调整方式把日期调整到工作日
+func adjustBusinessDay(calendar HolidayCalendar, convention BusinessDayConvention, date time.Time) (time.Time, error) {
+	if calendar == nil {
+		return date, nil
+	}
+	switch convention {
+	case BusinessDayFollowing:
+		return getNextBusinessDay(calendar, date, 1)
+	case BusinessDayModifiedFollowing:
+		adjustDate, err := getNextBusinessDay(calendar, date, 1)
+		if err != nil || adjustDate.Month() != date.Month() {
+			return getNextBusinessDay(calendar, date, -1)
+		}
+		return adjustDate, nil
+	case BusinessDayPreceding:
+		return getNextBusinessDay(calendar, date, -1)
+	}
+	return date, nil
+}
+
+// 从 date 开始按 step 方向(1 向后,-1 向前)查找第一个工作日,最多查找 maxBusinessDaySearchDays 天
+func getNextBusinessDay(calendar HolidayCalendar, date time.Time, step int) (time.Time, error) {
+	for i := 0; i < maxBusinessDaySearchDays; i++ {
+		if businessDay := date.AddDate(0, 0, i*step); calendar.IsBusinessDay(businessDay) {
+			return businessDay, nil
+		}
+	}
+	return time.Time{}, newValidationError("calendar", CodeInvalid,
+		"holiday Calendar error: no business day within "+strconv.Itoa(maxBusinessDaySearchDays)+" days of "+date.Format(DATE_DASH_FORMAT))
+}
+
+/**
+  *@Description 调整各期还款日:计息结束日随还款日调整时,本期结束日和下一期开始日随之调整,否则只调整还款日
+**/
+func adjustPeriodDate(request repayPlanRequest, dateMap map[int][]time.Time) error {
+	if request.BusinessDayConvention == "" || request.BusinessDayConvention == BusinessDayUnadjusted {
+		return nil
+	}
+	for i := 0; i < len(dateMap); i++ {
+		periodRepayDate, err := adjustBusinessDay(request.Calendar, request.BusinessDayConvention, dateMap[i][2])
+		if err != nil {
+			return err
+		}
+		dateMap[i][2] = periodRepayDate
+		if !request.AdjustInterestEndDate {
+			continue
+		}
+		dateMap[i][1] = periodRepayDate.AddDate(0, 0, -1)
+		if next, ok := dateMap[i+1]; ok {
+			next[0] = periodRepayDate
+		}
+	}
+	return nil
+}
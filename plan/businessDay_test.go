@@ -0,0 +1,145 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testHolidayFile = `
+# 2022年劳动节、国庆节
+2022-05-02
+2022-05-03
+2022-05-04
+2022-05-07 workday
+2022-10-03
+2022-10-04
+2022-10-05
+2022-10-06
+2022-10-07
+2022-10-08 workday
+2022-10-09 workday
+`
+
+func Test_adjustBusinessDay(t *testing.T) {
+	calendar, err := ParseHolidayCalendar(strings.NewReader(testHolidayFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		convention BusinessDayConvention
+		date       string
+		want       string
+	}{
+		{BusinessDayFollowing, "2022-10-01", "2022-10-08"},
+		{BusinessDayPreceding, "2022-10-01", "2022-09-30"},
+		{BusinessDayFollowing, "2022-07-31", "2022-08-01"},
+		{BusinessDayModifiedFollowing, "2022-07-31", "2022-07-29"},
+		{BusinessDayModifiedFollowing, "2022-04-30", "2022-04-29"},
+		{BusinessDayFollowing, "2022-05-01", "2022-05-05"},
+		{BusinessDayUnadjusted, "2022-10-01", "2022-10-01"},
+	}
+	for _, c := range cases {
+		date, _ := time.ParseInLocation(DATE_DASH_FORMAT, c.date, time.Local)
+		got, err := adjustBusinessDay(calendar, c.convention, date)
+		if err != nil || got.Format(DATE_DASH_FORMAT) != c.want {
+			t.Errorf("%s %s: got %s %v, want %s", c.convention, c.date, got.Format(DATE_DASH_FORMAT), err, c.want)
+		}
+	}
+	if _, err = ParseHolidayCalendar(strings.NewReader("2022-10-01 holiday")); err == nil {
+		t.Error("expected error for unknown tag")
+	}
+}
+
+/**
+  *@Description 等额本金 还款日顺延 计息结束日是否随还款日调整
+**/
+func Test_businessDayRepayDate(t *testing.T) {
+	calendar, err := ParseHolidayCalendar(strings.NewReader(testHolidayFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := &Request{
+		LoanAmount:            decimal.NewFromFloat(120000),
+		LoanStartDate:         "2022-01-01",
+		InterestRate:          decimal.NewFromFloat(6),
+		PeriodNum:             12,
+		RepayDay:              1,
+		LoanCycleCode:         LoanCycleMonthly,
+		RepayMethod:           EqualPrincipalRepayment,
+		PeriodType:            PeriodTypeMonth,
+		Calendar:              calendar,
+		BusinessDayConvention: BusinessDayFollowing,
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, next := resp.PlanRepayRecords[8], resp.PlanRepayRecords[9]
+	if record.PeriodRepayDate != "2022-10-08" || record.PeriodEndDate != "2022-09-30" || next.PeriodStartDate != "2022-10-01" {
+		t.Errorf("unexpected unadjusted accrual %+v", record)
+	}
+	if !record.PeriodRepayInterest.Equal(decimal.NewFromInt(200)) {
+		t.Errorf("interest %s, want 200", record.PeriodRepayInterest)
+	}
+
+	request.AdjustInterestEndDate = true
+	resp, err = CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, next = resp.PlanRepayRecords[8], resp.PlanRepayRecords[9]
+	if record.PeriodEndDate != "2022-10-07" || record.DaysOfPeriod != 37 || next.PeriodStartDate != "2022-10-08" || next.DaysOfPeriod != 24 {
+		t.Errorf("unexpected adjusted accrual %+v %+v", record, next)
+	}
+	if !record.PeriodRepayInterest.Equal(decimal.RequireFromString("246.67")) {
+		t.Errorf("interest %s, want 246.67", record.PeriodRepayInterest)
+	}
+}
+
+func Test_businessDayConventionError(t *testing.T) {
+	request := &Request{
+		LoanAmount:            decimal.NewFromFloat(120000),
+		LoanStartDate:         "2022-01-01",
+		InterestRate:          decimal.NewFromFloat(6),
+		PeriodNum:             12,
+		RepayDay:              1,
+		LoanCycleCode:         LoanCycleMonthly,
+		RepayMethod:           EqualPrincipalRepayment,
+		PeriodType:            PeriodTypeMonth,
+		BusinessDayConvention: BusinessDayFollowing,
+	}
+	if _, err := CalculateRepaymentPlan(request); err == nil {
+		t.Error("expected error for empty calendar")
+	}
+}
+
+// 没有工作日的节假日日历
+type noBusinessDayCalendar struct{}
+
+func (noBusinessDayCalendar) IsBusinessDay(time.Time) bool { return false }
+
+/**
+  *@Description 节假日日历一年内没有工作日时 还款日无法调整 返回参数错误
+**/
+func Test_businessDayNotFound(t *testing.T) {
+	for _, repayMethod := range []RepayMethod{EqualPrincipalRepayment, BothPrincipalAndInterest} {
+		request := &Request{
+			LoanAmount:            decimal.NewFromFloat(120000),
+			LoanStartDate:         "2022-01-01",
+			LoanEndDate:           "2023-01-01",
+			InterestRate:          decimal.NewFromFloat(6),
+			RepayDay:              1,
+			LoanCycleCode:         LoanCycleMonthly,
+			RepayMethod:           repayMethod,
+			PeriodType:            PeriodTypeMonth,
+			Calendar:              noBusinessDayCalendar{},
+			BusinessDayConvention: BusinessDayModifiedFollowing,
+		}
+		if _, err := CalculateRepaymentPlan(request); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: got %v, want calendar invalid", repayMethod, err)
+		}
+	}
+}
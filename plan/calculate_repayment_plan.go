@@ -1,11 +1,14 @@
-package main
+// Package plan 根据贷款金额、利率、期限和还款方式生成还款计划
+package plan
 
 import (
 	"errors"
 	"github.com/shopspring/decimal"
+	"strconv"
 	"time"
 )
 
+// CalculateRepaymentPlan 校验请求参数并生成还款计划
 func CalculateRepaymentPlan(request *Request) (response *Response, err error) {
 	if err = check(request); nil != err {
 		return nil, err
@@ -13,138 +16,206 @@ func CalculateRepaymentPlan(request *Request) (response *Response, err error) {
 	return getRepaymentPlan(request)
 }
 
-// request 参数检查
+// request 参数检查 一次返回全部参数错误
 func check(request *Request) error {
+	var errs ValidationErrors
 
 	if request.DaysOfYear == 0 {
 		request.DaysOfYear = daysOfYear
 	}
+	errs.merge(checkDayCountConvention(request.DayCountConvention))
+	if request.DayCountConvention == "" {
+		request.DayCountConvention = getDefaultDayCountConvention(request.DaysOfYear)
+	}
+	// 计息基准覆盖年天数,期利率、日利率与利息按同一年天数计算
+	request.DaysOfYear = getDaysOfYearOfConvention(request.DayCountConvention, request.DaysOfYear)
+	errs.merge(checkRoundingPolicy(&request.RoundingPolicy))
 	if request.InterestRate.LessThanOrEqual(decimal.Zero) {
-		return errors.New("interest Rate error")
+		errs.add("interestRate", CodeInvalid, "interest Rate error")
 	}
+	errs.merge(checkRateSchedule(request))
 	if request.LoanAmount.LessThanOrEqual(decimal.Zero) {
-		return errors.New("loan Amount error")
+		errs.add("loanAmount", CodeInvalid, "loan Amount error")
 	}
-	if e := checkLoanCycleCode(request.LoanCycleCode); nil != e {
-		return e
+	if request.UpfrontFee.IsNegative() || (request.LoanAmount.IsPositive() && request.UpfrontFee.GreaterThanOrEqual(request.LoanAmount)) {
+		errs.add("upfrontFee", CodeInvalid, "upfront Fee error")
 	}
+	errs.merge(checkFeeSchedules(request.FeeSchedules))
+	errs.merge(checkLoanCycleCode(request.LoanCycleCode))
 	if request.PeriodNum < 0 {
-		return errors.New("period Num error")
+		errs.add("periodNum", CodeInvalid, "period Num error")
+	} else if request.PeriodNum > MaxPeriodNum {
+		errs.add("periodNum", CodeInvalid, "period Num must be less than or equal to "+strconv.Itoa(MaxPeriodNum))
 	}
 	if request.LoanStartDate == "" {
-		return errors.New("interest Calculate Start Date can not be empty")
+		errs.add("loanStartDate", CodeRequired, "interest Calculate Start Date can not be empty")
 	}
-	loanStartDate, e := time.ParseInLocation(DATE_DASH_FORMAT, request.LoanStartDate, time.Local)
-	if nil != e {
-		return errors.New("interest Calculate Start Date error")
+	loanStartDate, startDateErr := time.ParseInLocation(DATE_DASH_FORMAT, request.LoanStartDate, time.Local)
+	if request.LoanStartDate != "" && nil != startDateErr {
+		errs.add("loanStartDate", CodeInvalid, "interest Calculate Start Date error")
 	}
 	if request.LoanEndDate != "" {
 		loanEndDate, e := time.ParseInLocation(DATE_DASH_FORMAT, request.LoanEndDate, time.Local)
 		if nil != e {
-			return errors.New("interest Calculate End Date error")
-		}
-		if loanEndDate.Sub(loanStartDate) <= 0 {
-			return errors.New("loan Start Date can not after or equal than loan end date")
+			errs.add("loanEndDate", CodeInvalid, "interest Calculate End Date error")
+		} else if nil == startDateErr && loanEndDate.Sub(loanStartDate) <= 0 {
+			errs.add("loanEndDate", CodeConflict, "loan Start Date can not after or equal than loan end date")
 		}
 	}
 
-	if request.RepayDay <= 0 || request.RepayDay >= 32 {
-		return errors.New("repay Day error")
-	}
-	if e := checkPeriodType(request.PeriodType); nil != e {
-		return e
+	// 按日还款不需要还款日
+	if request.LoanCycleCode != LoanCycleDaily && (request.RepayDay <= 0 || request.RepayDay >= 32) {
+		errs.add("repayDay", CodeInvalid, "repay Day error")
 	}
-	switch request.RepayMethod {
-	case EqualLoanRepayment, EqualPrincipalRepayment, BeforeInterestAfterPrincipal, EqualPrincipalAndInterest:
-		if request.PeriodNum == 0 && request.LoanEndDate == "" {
-			return errors.New("loanEndDate and periodNum can not be empty at the same time")
+	if request.LoanCycleCode == LoanCycleQuarterly {
+		if request.RepayMonthOfQuarter == 0 {
+			request.RepayMonthOfQuarter = defaultRepayMonthOfQuarter
 		}
-	case BothPrincipalAndInterest:
-		if request.LoanEndDate == "" {
-			return errors.New("interest Calculate End Date can not be empty")
+		if request.RepayMonthOfQuarter < 1 || request.RepayMonthOfQuarter > numberOfMonthsOfQuarter {
+			errs.add("repayMonthOfQuarter", CodeInvalid, "repay Month Of Quarter error")
 		}
 	}
-	return nil
+	errs.merge(checkBusinessDayConvention(request))
+	errs.merge(checkBalloon(request))
+	errs.merge(checkGraduated(request))
+	errs.merge(checkPrincipalSchedule(request))
+	errs.merge(checkGracePeriod(request))
+	errs.merge(checkPeriodType(request.PeriodType))
+	// 还款方式特有的参数检查
+	if request.RepayMethod == "" {
+		errs.add("repayMethod", CodeRequired, "repay method can not be empty")
+	} else if method, ok := GetRepaymentMethod(request.RepayMethod); ok {
+		errs.merge(method.Check(request))
+	} else {
+		errs.add("repayMethod", CodeInvalid, "repay method error")
+	}
+	return errs.err()
 }
-func checkLoanCycleCode(loanCycleCode string) error {
+func checkLoanCycleCode(loanCycleCode LoanCycleCode) error {
 	switch loanCycleCode {
-	case loanCycleDaily, loanCycleFortnightly, loanCycleMonthly, loanCycleQuarterly, loanCycleYearly:
+	case LoanCycleDaily, LoanCycleFortnightly, LoanCycleMonthly, LoanCycleQuarterly, LoanCycleYearly:
 		return nil
 	default:
-		return errors.New("loan Cycle Code error")
+		return newValidationError("loanCycleCode", CodeInvalid, "loan Cycle Code error")
 	}
 }
-func checkPeriodType(periodType string) error {
+func checkPeriodType(periodType PeriodType) error {
 	switch periodType {
-	case periodTypeYear, periodTypeMonth:
+	case PeriodTypeYear, PeriodTypeMonth:
 		return nil
 	default:
-		return errors.New("period type error")
+		return newValidationError("periodType", CodeInvalid, "period type error")
 	}
 }
 
 func getRepaymentPlan(request *Request) (response *Response, err error) {
+	if response, err = getRepaymentSchedule(request); err != nil {
+		return nil, err
+	}
+	// 按现金流计算实际年化利率
+	if err = fillAnnualRate(response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
 
-	var repayPlanRequest repayPlanRequest
-	switch request.RepayMethod {
-	case EqualLoanRepayment, EqualPrincipalRepayment, BeforeInterestAfterPrincipal, EqualPrincipalAndInterest:
-		repayPlanRequest, response, err = prepareGetParameter(request)
-	}
-
-	switch request.RepayMethod {
-	// 01-等额本息
-	case EqualLoanRepayment:
-		err = fixedInstallmentMethodPlan(repayPlanRequest, response)
-	// 02-等额本金
-	case EqualPrincipalRepayment:
-		err = fixedPrincipalMethodPlan(repayPlanRequest, response)
-	// 03-利随本清
-	case BothPrincipalAndInterest:
-		response, err = bothPrincipalAndInterest(request)
-	// 04-先息后本
-	case BeforeInterestAfterPrincipal:
-		err = beforeInterestAfterPrincipalPlan(repayPlanRequest, response)
-	// 05-等本等息
-	case EqualPrincipalAndInterest:
-		err = equalPrincipalAndInterestPlan(repayPlanRequest, response)
-	default:
-		return nil, errors.New("repay method error")
+// 生成各期还款金额和费用 不计算实际年化利率
+func getRepaymentSchedule(request *Request) (response *Response, err error) {
+
+	// 计入本金的费用与贷款金额一起分期偿还,按贷款金额收取的费用仍按原贷款金额计算
+	loanAmount := request.LoanAmount
+	cashFee, financedFee := getUpfrontFees(request)
+	if financedFee.IsPositive() {
+		financedRequest := *request
+		financedRequest.LoanAmount = loanAmount.Add(financedFee)
+		request = &financedRequest
+	}
+
+	method, ok := GetRepaymentMethod(request.RepayMethod)
+	if !ok {
+		return nil, newValidationError("repayMethod", CodeInvalid, "repay method error")
 	}
-	return response, err
+	response, err = method.Plan(request)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil || len(response.PlanRepayRecords) == 0 {
+		return nil, errors.New("repay plan can not be empty: " + string(request.RepayMethod))
+	}
+
+	fillFees(request, response, loanAmount, cashFee, financedFee)
+	return response, nil
 }
 func prepareGetParameter(request *Request) (repayPlanRequest, *Response, error) {
 	loanStartDateParseLocal, err := time.ParseInLocation(DATE_DASH_FORMAT, request.LoanStartDate, time.Local)
 	if err != nil {
-		return repayPlanRequest{}, nil, errors.New("loanStartDate date format error: " + err.Error())
+		return repayPlanRequest{}, nil, newValidationError("loanStartDate", CodeInvalid, "loanStartDate date format error: "+err.Error())
 	}
 
 	firstRepayDate, err := getFirstRepayDate(request, loanStartDateParseLocal)
 	if err != nil {
 		return repayPlanRequest{}, nil, err
 	}
-	totalPeriodNum, err := getTotalPeriodNum(request, loanStartDateParseLocal)
-
-	err = getLoanEndDate(request, firstRepayDate)
+	totalPeriodNum, err := getTotalPeriodNum(request, loanStartDateParseLocal, firstRepayDate)
+	if err != nil {
+		return repayPlanRequest{}, nil, err
+	}
+	if totalPeriodNum <= 0 {
+		return repayPlanRequest{}, nil, newValidationError("periodNum", CodeInvalid, "total period num must be greater than 0")
+	}
+	// 按年的期数或贷款结束日期换算后的总期数同样不能超过上限
+	if totalPeriodNum > MaxPeriodNum {
+		return repayPlanRequest{}, nil, newValidationError("periodNum", CodeInvalid,
+			"total period num "+strconv.Itoa(totalPeriodNum)+" must be less than or equal to "+strconv.Itoa(MaxPeriodNum))
+	}
+	if err = getLoanEndDate(request, firstRepayDate, totalPeriodNum); err != nil {
+		return repayPlanRequest{}, nil, err
+	}
 
 	loanEndDateParseLocal, err := time.ParseInLocation(DATE_DASH_FORMAT, request.LoanEndDate, time.Local)
 	if err != nil {
-		return repayPlanRequest{}, nil, errors.New("loanStartDate date format error: " + err.Error())
+		return repayPlanRequest{}, nil, newValidationError("loanEndDate", CodeInvalid, "loanEndDate date format error: "+err.Error())
+	}
+	if request.GracePeriodNum >= totalPeriodNum {
+		return repayPlanRequest{}, nil, newValidationError("gracePeriodNum", CodeConflict, "grace Period Num must be less than total period num")
+	}
+
+	// 利率调整计划,浮动利率按重定价日展开
+	rateSchedule, err := getRateSchedule(request, loanStartDateParseLocal, loanEndDateParseLocal)
+	if err != nil {
+		return repayPlanRequest{}, nil, err
+	}
+	rateCurve, err := parseRateCurve(rateSchedule)
+	if err != nil {
+		return repayPlanRequest{}, nil, err
+	}
+	startInterestRate := request.InterestRate
+	if rate, ok := getCurveRate(rateCurve, loanStartDateParseLocal); ok {
+		startInterestRate = rate
 	}
 
-	periodInterestRate := calculatePeriodInterestRate(request.InterestRate, request.LoanCycleCode)
+	periodInterestRate := calculatePeriodInterestRate(startInterestRate, request.LoanCycleCode, request.DaysOfYear)
 
-	daysInterestRate := calculateDaysInterestRate(request.InterestRate, request.DaysOfYear)
+	daysInterestRate := calculateDaysInterestRate(startInterestRate, request.DaysOfYear)
 
 	response := &Response{
-		RepayMethod:    request.RepayMethod,
-		LoanStartDate:  request.LoanStartDate,
-		LoanEndDate:    request.LoanEndDate,
-		TotalPeriodNum: totalPeriodNum,
-		LoanAmount:     request.LoanAmount,
-		InterestRate:   request.InterestRate,
+		RepayMethod:           request.RepayMethod,
+		LoanStartDate:         request.LoanStartDate,
+		LoanEndDate:           request.LoanEndDate,
+		TotalPeriodNum:        totalPeriodNum,
+		LoanAmount:            request.LoanAmount,
+		InterestRate:          request.InterestRate,
+		LoanCycleCode:         request.LoanCycleCode,
+		DaysOfYear:            request.DaysOfYear,
+		DayCountConvention:    request.DayCountConvention,
+		RoundingPolicy:        request.RoundingPolicy,
+		RateSchedule:          rateSchedule,
+		BusinessDayConvention: request.BusinessDayConvention,
+		GracePeriodNum:        request.GracePeriodNum,
+		GraceType:             request.GraceType,
 	}
 
-	return repayPlanRequest{
+	planRequest := repayPlanRequest{
 		LoanAmount:              request.LoanAmount,
 		LoanStartDate:           request.LoanStartDate,
 		LoanEndDate:             request.LoanEndDate,
@@ -156,5 +227,25 @@ func prepareGetParameter(request *Request) (repayPlanRequest, *Response, error)
 		LoanEndDateParseLocal:   loanEndDateParseLocal,
 		RepayDay:                request.RepayDay,
 		DaysInterestRate:        daysInterestRate,
-	}, response, nil
+		InterestRate:            request.InterestRate,
+		DaysOfYear:              request.DaysOfYear,
+		DayCountConvention:      request.DayCountConvention,
+		RoundingPolicy:          request.RoundingPolicy,
+		RateCurve:               rateCurve,
+		Calendar:                request.Calendar,
+		BusinessDayConvention:   request.BusinessDayConvention,
+		AdjustInterestEndDate:   request.AdjustInterestEndDate,
+		GracePeriodNum:          request.GracePeriodNum,
+		GraceType:               request.GraceType,
+		BalloonAmount:           getBalloonAmount(request),
+		StepPeriodNum:           request.StepPeriodNum,
+		StepRate:                request.StepRate,
+		StepAmount:              request.StepAmount,
+		PrincipalSchedule:       request.PrincipalSchedule,
+	}
+	// 各期日期只计算一次,还款日调整失败时在生成还款计划前返回错误
+	if planRequest.PeriodDates, err = calculatePeriodDate(planRequest); err != nil {
+		return repayPlanRequest{}, nil, err
+	}
+	return planRequest, response, nil
 }
@@ -1,4 +1,4 @@
-package main
+package plan
 
 import (
 	"fmt"
@@ -94,7 +94,118 @@ func Test_FixedPrincipalMethod(t *testing.T) {
 
 	}
 }
-func getRepayMethod(repayMethod string) string {
+
+/**
+  *@Description 等额本息 按季还款 每季第三个月20号还款
+**/
+func Test_loanCycleQuarterly(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-10",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     2, // 2年8个季度
+		RepayDay:      20,
+		LoanCycleCode: LoanCycleQuarterly,
+		RepayMethod:   EqualLoanRepayment,
+		PeriodType:    PeriodTypeYear,
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TotalPeriodNum != 8 || resp.LoanEndDate != "2023-12-20" {
+		t.Errorf("got %d periods ending %s, want 8 periods ending 2023-12-20", resp.TotalPeriodNum, resp.LoanEndDate)
+	}
+	wantRepayDates := []string{"2022-03-20", "2022-06-20", "2022-09-20", "2022-12-20", "2023-03-20", "2023-06-20", "2023-09-20", "2023-12-20"}
+	for i, item := range resp.PlanRepayRecords {
+		if item.PeriodRepayDate != wantRepayDates[i] {
+			t.Errorf("period %d repay date %s, want %s", item.PeriodNum, item.PeriodRepayDate, wantRepayDates[i])
+		}
+	}
+	if !resp.PlanRepayRecords[7].MaintainPrinciple.IsZero() {
+		t.Errorf("maintain principle %s after last period", resp.PlanRepayRecords[7].MaintainPrinciple)
+	}
+}
+
+/**
+  *@Description 等额本金 按年还款
+**/
+func Test_loanCycleYearly(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-10",
+		LoanEndDate:   "2025-01-10",
+		InterestRate:  decimal.NewFromFloat(6),
+		RepayDay:      10,
+		LoanCycleCode: LoanCycleYearly,
+		RepayMethod:   EqualPrincipalRepayment,
+		PeriodType:    PeriodTypeMonth,
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TotalPeriodNum != 3 {
+		t.Fatalf("got %d periods, want 3", resp.TotalPeriodNum)
+	}
+	first := resp.PlanRepayRecords[0]
+	if first.PeriodRepayDate != "2023-01-10" || first.DaysOfPeriod != 365 || !first.PeriodRepayInterest.Equal(decimal.NewFromInt(7300)) {
+		t.Errorf("unexpected first period %+v", first)
+	}
+}
+
+/**
+  *@Description 等额本息 按年还款 起息日至第一个还款日不足240天的顺延一年
+**/
+func Test_loanCycleYearlyShortFirstPeriod(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromFloat(100000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(10),
+		PeriodNum:     3,
+		RepayDay:      28,
+		LoanCycleCode: LoanCycleYearly,
+		RepayMethod:   EqualLoanRepayment,
+		PeriodType:    PeriodTypeMonth,
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := resp.PlanRepayRecords[0]
+	if resp.LoanEndDate != "2025-01-28" || first.PeriodRepayDate != "2023-01-28" || first.DaysOfPeriod != 392 {
+		t.Errorf("got end date %s first period %s %d days, want 2025-01-28 2023-01-28 392 days", resp.LoanEndDate, first.PeriodRepayDate, first.DaysOfPeriod)
+	}
+}
+
+/**
+  *@Description 先息后本 按日还款
+**/
+func Test_loanCycleDaily(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromFloat(3000),
+		LoanStartDate: "2022-01-10",
+		InterestRate:  decimal.NewFromFloat(3.6),
+		PeriodNum:     10,
+		LoanCycleCode: LoanCycleDaily,
+		RepayMethod:   BeforeInterestAfterPrincipal,
+		PeriodType:    PeriodTypeMonth,
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.LoanEndDate != "2022-01-20" || !resp.TotalInterest.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("got end date %s total interest %s, want 2022-01-20 and 3", resp.LoanEndDate, resp.TotalInterest)
+	}
+	for _, item := range resp.PlanRepayRecords {
+		if item.DaysOfPeriod != 1 {
+			t.Errorf("period %d has %d days, want 1", item.PeriodNum, item.DaysOfPeriod)
+		}
+	}
+}
+
+func getRepayMethod(repayMethod RepayMethod) string {
 	switch repayMethod {
 	case EqualLoanRepayment:
 		return "等额本息"
@@ -106,15 +217,23 @@ func getRepayMethod(repayMethod string) string {
 		return "先息后本"
 	case EqualPrincipalAndInterest:
 		return "等本等息"
+	case BalloonRepayment:
+		return "气球贷"
 	}
 	return ""
 }
-func getLoanCycleCode(loanCycleCode string) string {
+func getLoanCycleCode(loanCycleCode LoanCycleCode) string {
 	switch loanCycleCode {
-	case loanCycleFortnightly:
+	case LoanCycleDaily:
+		return "日"
+	case LoanCycleFortnightly:
 		return "两周"
-	case loanCycleMonthly:
+	case LoanCycleMonthly:
 		return "月"
+	case LoanCycleQuarterly:
+		return "季"
+	case LoanCycleYearly:
+		return "年"
 	}
 	return ""
 }
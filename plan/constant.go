@@ -1,33 +1,56 @@
-package main
+package plan
+
+// RepayMethod 还款方式
+type RepayMethod string
 
 // 还款方式
 const (
-	EqualLoanRepayment           = "1" // 等额本息
-	EqualPrincipalRepayment      = "2" // 等额本金
-	BothPrincipalAndInterest     = "3" // 息随本清
-	BeforeInterestAfterPrincipal = "4" // 先息后本
-	EqualPrincipalAndInterest    = "5" // 等本等息
+	EqualLoanRepayment           RepayMethod = "1" // 等额本息
+	EqualPrincipalRepayment      RepayMethod = "2" // 等额本金
+	BothPrincipalAndInterest     RepayMethod = "3" // 息随本清
+	BeforeInterestAfterPrincipal RepayMethod = "4" // 先息后本
+	EqualPrincipalAndInterest    RepayMethod = "5" // 等本等息
+	BalloonRepayment             RepayMethod = "6" // 气球贷(尾款贷)
+	GraduatedRepayment           RepayMethod = "7" // 阶梯还款(递增或递减)
+	RuleOf78Repayment            RepayMethod = "8" // 78法则(按期数倒序分摊利息)
+	CustomPrincipalRepayment     RepayMethod = "9" // 自定义本金(按约定的本金计划还本)
 )
 
+// LoanCycleCode 还款周期频率
+type LoanCycleCode string
+
+// 还款周期频率
 const (
-	DATE_DASH_FORMAT = "2006-01-02"
+	LoanCycleDaily       LoanCycleCode = "01" // 日
+	LoanCycleFortnightly LoanCycleCode = "02" // 两周
+	LoanCycleMonthly     LoanCycleCode = "03" // 月
+	LoanCycleQuarterly   LoanCycleCode = "04" // 季
+	LoanCycleYearly      LoanCycleCode = "05" // 年
 )
 
+// PeriodType 期数类型
+type PeriodType string
+
+// 期数类型
 const (
-	daysOfYear    = 360
-	numberOfWeek  = 26
-	numberOfMonth = 12
+	PeriodTypeYear  PeriodType = "01" // 年
+	PeriodTypeMonth PeriodType = "02" // 月
 )
 
 const (
-	loanCycleDaily       = "01" // 日
-	loanCycleFortnightly = "02" // 两周
-	loanCycleMonthly     = "03" // 月
-	loanCycleQuarterly   = "04" // 季
-	loanCycleYearly      = "05" // 年
+	DATE_DASH_FORMAT = "2006-01-02"
 )
 
+// MaxPeriodNum 总期数上限 按日还款约30年,超过时参数检查不通过
+const MaxPeriodNum = 11000
+
 const (
-	periodTypeYear  = "01"
-	periodTypeMonth = "02"
+	daysOfYear    = 360
+	numberOfWeek  = 26
+	numberOfMonth = 12
+
+	numberOfQuarter         = 4
+	numberOfMonthsOfQuarter = 3
+
+	defaultRepayMonthOfQuarter = 3
 )
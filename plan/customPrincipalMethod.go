@@ -0,0 +1,99 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"sort"
+	"strconv"
+)
+
+// PrincipalRepayment 自定义本金计划中一期归还的本金,按金额或按贷款金额的比例
+// 有计入本金的费用时贷款金额为计入费用后的本金,即Response中的LoanAmount
+type PrincipalRepayment struct {
+	PeriodNum int             `json:"periodNum"` // 期次
+	Amount    decimal.Decimal `json:"amount"`    // 归还的本金金额
+	Percent   decimal.Decimal `json:"percent"`   // 归还的本金比例(%) 本金=贷款金额(含计入本金的费用)×比例,与金额设置其一
+}
+
+/**
+  *@Description 自定义本金：按约定的本金计划在各期归还本金，未列出的期次只还利息，每期利息按剩余本金和实际计息天数计算
+**/
+func customPrincipalMethodPlan(request repayPlanRequest, response *Response) error {
+	principles, err := getSchedulePrinciples(request)
+	if err != nil {
+		return err
+	}
+	fillRepayPlanRecords(response, calculatePrincipalScheduleRecords(request, request.PeriodDates, principles))
+	response.PrincipalSchedule = request.PrincipalSchedule
+	return nil
+}
+
+func checkPrincipalSchedule(request *Request) error {
+	if request.RepayMethod != CustomPrincipalRepayment {
+		if len(request.PrincipalSchedule) == 0 {
+			return nil
+		}
+		return newValidationError("principalSchedule", CodeUnsupported, "repay method error: principal schedule only supported by custom principal repayment")
+	}
+	if len(request.PrincipalSchedule) == 0 {
+		return newValidationError("principalSchedule", CodeRequired, "principal Schedule can not be empty")
+	}
+	var errs ValidationErrors
+	periods := make(map[int]bool)
+	for i, repayment := range request.PrincipalSchedule {
+		field := "principalSchedule[" + strconv.Itoa(i) + "]."
+		if repayment.PeriodNum < 1 {
+			errs.add(field+"periodNum", CodeInvalid, "principal Schedule period Num error")
+		} else if periods[repayment.PeriodNum] {
+			errs.add(field+"periodNum", CodeConflict, "principal Schedule period Num duplicated: "+strconv.Itoa(repayment.PeriodNum))
+		}
+		periods[repayment.PeriodNum] = true
+		if repayment.Amount.IsZero() == repayment.Percent.IsZero() {
+			errs.add(field+"amount", CodeConflict, "principal Schedule amount and percent must be set one of them")
+		}
+		if repayment.Amount.IsNegative() {
+			errs.add(field+"amount", CodeInvalid, "principal Schedule amount error")
+		}
+		if repayment.Percent.IsNegative() || repayment.Percent.GreaterThan(decimal.NewFromInt(100)) {
+			errs.add(field+"percent", CodeInvalid, "principal Schedule percent error")
+		}
+	}
+	return errs.err()
+}
+
+// 各期归还的本金:按比例的本金舍入后的尾差放在本金计划的最后一期,各期本金之和必须等于贷款金额
+// request.LoanAmount 已包含计入本金的费用,按比例和合计校验都以计入费用后的本金为准
+func getSchedulePrinciples(request repayPlanRequest) ([]decimal.Decimal, error) {
+	schedule := make([]PrincipalRepayment, len(request.PrincipalSchedule))
+	copy(schedule, request.PrincipalSchedule)
+	sort.Slice(schedule, func(i, j int) bool {
+		return schedule[i].PeriodNum < schedule[j].PeriodNum
+	})
+	if last := schedule[len(schedule)-1]; last.PeriodNum > request.TotalPeriodNum {
+		return nil, newValidationError("principalSchedule", CodeConflict,
+			"principal Schedule period Num must be less than or equal to total period num: "+strconv.Itoa(last.PeriodNum))
+	}
+
+	principles := make([]decimal.Decimal, request.TotalPeriodNum)
+	total, amountTotal, percentTotal := decimal.Zero, decimal.Zero, decimal.Zero
+	for _, repayment := range schedule {
+		principle := repayment.Amount
+		amountTotal = amountTotal.Add(repayment.Amount)
+		if principle.IsZero() {
+			principle = roundAmount(request.LoanAmount.Mul(repayment.Percent).Div(decimal.NewFromInt(100)), request.RoundingPolicy)
+			percentTotal = percentTotal.Add(repayment.Percent)
+		}
+		principles[repayment.PeriodNum-1] = principle
+		total = total.Add(principle)
+	}
+	// 全部按比例归还且合计为100%时,舍入产生的尾差不视为错误
+	if amountTotal.IsZero() && percentTotal.Equal(decimal.NewFromInt(100)) {
+		lastPeriod := schedule[len(schedule)-1].PeriodNum - 1
+		principles[lastPeriod] = principles[lastPeriod].Add(request.LoanAmount.Sub(total))
+		total = request.LoanAmount
+	}
+	if !total.Equal(request.LoanAmount) {
+		return nil, newValidationError("principalSchedule", CodeConflict,
+			"principal Schedule total "+total.String()+" must be equal to loan amount including financed fee "+request.LoanAmount.String())
+	}
+	return principles, nil
+}
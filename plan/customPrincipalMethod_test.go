@@ -0,0 +1,143 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+/**
+  *@Description 自定义本金 第6期归还10% 第12期归还90% 其他期次只还利息
+**/
+func Test_customPrincipalMethod(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:    decimal.NewFromFloat(1000000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(5),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   CustomPrincipalRepayment,
+		PeriodType:    PeriodTypeMonth,
+		PrincipalSchedule: []PrincipalRepayment{
+			{PeriodNum: 12, Percent: decimal.NewFromInt(90)},
+			{PeriodNum: 6, Percent: decimal.NewFromInt(10)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := resp.PlanRepayRecords
+	// 第1期利息=1000000×31×5%/360
+	if !records[0].PeriodRepayPrinciple.IsZero() || !records[0].PeriodRepayInterest.Equal(decimal.RequireFromString("4305.56")) {
+		t.Errorf("unexpected period 1 %+v", records[0])
+	}
+	if !records[5].PeriodRepayPrinciple.Equal(decimal.NewFromInt(100000)) || !records[5].MaintainPrinciple.Equal(decimal.NewFromInt(900000)) {
+		t.Errorf("unexpected period 6 %+v", records[5])
+	}
+	// 第7期利息按剩余本金900000计算=900000×31×5%/360
+	if !records[6].PeriodRepayInterest.Equal(decimal.RequireFromString("3875")) {
+		t.Errorf("got period 7 interest %s, want 3875", records[6].PeriodRepayInterest)
+	}
+	if !records[11].PeriodRepayPrinciple.Equal(decimal.NewFromInt(900000)) || !records[11].MaintainPrinciple.IsZero() {
+		t.Errorf("unexpected last period %+v", records[11])
+	}
+	if !resp.TotalRepayAmount.Equal(resp.LoanAmount.Add(resp.TotalInterest)) || len(resp.PrincipalSchedule) != 2 {
+		t.Errorf("got total repay amount %s total interest %s", resp.TotalRepayAmount, resp.TotalInterest)
+	}
+}
+
+/**
+  *@Description 自定义本金 按金额或比例归还 有计入本金的手续费时比例和合计以计入手续费后的本金为准 本金之和不一致或期次超过总期数时报错
+**/
+func Test_customPrincipalMethodSchedule(t *testing.T) {
+	financedFee := []FeeSchedule{{Name: "手续费", ChargeType: FeeChargeUpfront, Basis: FeeBasisPrincipal, Rate: decimal.NewFromInt(1), Financed: true}}
+	cases := []struct {
+		name       string
+		schedule   []PrincipalRepayment
+		fees       []FeeSchedule
+		principles map[int]int64 // 期次对应的本金 为空时期望冲突错误
+	}{
+		{"amount", []PrincipalRepayment{{PeriodNum: 3, Amount: decimal.NewFromInt(300000)}, {PeriodNum: 12, Amount: decimal.NewFromInt(700000)}},
+			nil, map[int]int64{3: 300000, 12: 700000}},
+		{"total", []PrincipalRepayment{{PeriodNum: 3, Amount: decimal.NewFromInt(300000)}, {PeriodNum: 12, Percent: decimal.NewFromInt(60)}},
+			nil, nil},
+		{"period", []PrincipalRepayment{{PeriodNum: 13, Percent: decimal.NewFromInt(100)}}, nil, nil},
+		{"financed percent", []PrincipalRepayment{{PeriodNum: 6, Percent: decimal.NewFromInt(10)}, {PeriodNum: 12, Percent: decimal.NewFromInt(90)}},
+			financedFee, map[int]int64{6: 101000, 12: 909000}},
+		// 按金额设置时合计须包含计入本金的手续费
+		{"financed amount", []PrincipalRepayment{{PeriodNum: 12, Amount: decimal.NewFromInt(1010000)}}, financedFee, map[int]int64{12: 1010000}},
+		{"financed total", []PrincipalRepayment{{PeriodNum: 12, Amount: decimal.NewFromInt(1000000)}}, financedFee, nil},
+	}
+	for _, c := range cases {
+		resp, err := CalculateRepaymentPlan(&Request{
+			LoanAmount:        decimal.NewFromFloat(1000000),
+			LoanStartDate:     "2022-01-01",
+			InterestRate:      decimal.NewFromFloat(5),
+			PeriodNum:         12,
+			RepayDay:          1,
+			LoanCycleCode:     LoanCycleMonthly,
+			RepayMethod:       CustomPrincipalRepayment,
+			PeriodType:        PeriodTypeMonth,
+			FeeSchedules:      c.fees,
+			PrincipalSchedule: c.schedule,
+		})
+		if c.principles == nil {
+			if !errors.Is(err, ErrConflict) {
+				t.Errorf("%s: got %v, want principal schedule conflict", c.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		for _, record := range resp.PlanRepayRecords {
+			if !record.PeriodRepayPrinciple.Equal(decimal.NewFromInt(c.principles[record.PeriodNum])) {
+				t.Errorf("%s: got period %d principle %s, want %d", c.name, record.PeriodNum, record.PeriodRepayPrinciple, c.principles[record.PeriodNum])
+			}
+		}
+		if !resp.PlanRepayRecords[11].MaintainPrinciple.IsZero() {
+			t.Errorf("%s: unexpected last period %+v", c.name, resp.PlanRepayRecords[11])
+		}
+	}
+}
+
+func Test_checkPrincipalSchedule(t *testing.T) {
+	cases := []struct {
+		repayMethod RepayMethod
+		schedule    []PrincipalRepayment
+		fields      []string
+		code        ErrorCode
+	}{
+		{CustomPrincipalRepayment, []PrincipalRepayment{
+			{PeriodNum: 6, Percent: decimal.NewFromInt(10), Amount: decimal.NewFromInt(1)},
+			{PeriodNum: 6, Percent: decimal.NewFromInt(90)},
+		}, []string{"principalSchedule[0].amount", "principalSchedule[1].periodNum"}, CodeConflict},
+		{CustomPrincipalRepayment, nil, []string{"principalSchedule"}, CodeRequired},
+		{EqualPrincipalRepayment, []PrincipalRepayment{{PeriodNum: 12, Percent: decimal.NewFromInt(100)}}, []string{"principalSchedule"}, CodeUnsupported},
+	}
+	for _, c := range cases {
+		_, err := CalculateRepaymentPlan(&Request{
+			LoanAmount:        decimal.NewFromFloat(1000000),
+			LoanStartDate:     "2022-01-01",
+			InterestRate:      decimal.NewFromFloat(5),
+			PeriodNum:         12,
+			RepayDay:          1,
+			LoanCycleCode:     LoanCycleMonthly,
+			RepayMethod:       c.repayMethod,
+			PeriodType:        PeriodTypeMonth,
+			PrincipalSchedule: c.schedule,
+		})
+		var validationErrors ValidationErrors
+		if !errors.As(err, &validationErrors) || len(validationErrors) != len(c.fields) {
+			t.Errorf("%s %v: got %v", c.repayMethod, c.fields, err)
+			continue
+		}
+		for i, field := range c.fields {
+			if validationErrors[i].Field != field || validationErrors[i].Code != c.code {
+				t.Errorf("%s: error %d got %+v, want %s %s", c.repayMethod, i, validationErrors[i], field, c.code)
+			}
+		}
+	}
+}
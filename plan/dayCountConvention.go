@@ -0,0 +1,107 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// DayCountConvention 计息基准:决定计息天数和年天数
+type DayCountConvention string
+
+// 计息基准
+const (
+	DayCountAct360     DayCountConvention = "ACT/360"      // 实际天数/360
+	DayCountAct365F    DayCountConvention = "ACT/365F"     // 实际天数/365
+	DayCountActActISDA DayCountConvention = "ACT/ACT ISDA" // 实际天数/当年实际天数(跨年按年拆分)
+	DayCount30360      DayCountConvention = "30/360"       // 每月按30天,一年360天
+	DayCount30E360     DayCountConvention = "30E/360"      // 欧式30/360
+)
+
+func checkDayCountConvention(dayCountConvention DayCountConvention) error {
+	switch dayCountConvention {
+	case "", DayCountAct360, DayCountAct365F, DayCountActActISDA, DayCount30360, DayCount30E360:
+		return nil
+	default:
+		return newValidationError("dayCountConvention", CodeInvalid, "day Count Convention error")
+	}
+}
+
+// 未指定计息基准时按年天数推断,无法对应的保持为空(实际天数/年天数)
+func getDefaultDayCountConvention(daysOfYear int) DayCountConvention {
+	switch daysOfYear {
+	case 360:
+		return DayCountAct360
+	case 365:
+		return DayCountAct365F
+	}
+	return ""
+}
+
+// 计息基准对应的年天数,ACT/ACT ISDA 计息时按自然年拆分,期利率和日利率按365天;未设置计息基准时按年天数
+func getDaysOfYearOfConvention(dayCountConvention DayCountConvention, daysOfYear int) int {
+	switch dayCountConvention {
+	case DayCountAct360, DayCount30360, DayCount30E360:
+		return 360
+	case DayCountAct365F, DayCountActActISDA:
+		return 365
+	}
+	return daysOfYear
+}
+
+// calculateInterest 计算本金从 startDate 到 repayDate(不含)的利息(未舍入)和计息天数
+func calculateInterest(principal, interestRate decimal.Decimal, dayCountConvention DayCountConvention, daysOfYear int,
+	startDate, repayDate time.Time) (decimal.Decimal, int64) {
+	if dayCountConvention == DayCountActActISDA {
+		return calculateInterestOfActActISDA(principal, interestRate, startDate, repayDate)
+	}
+	days := getDaysOfPeriod(dayCountConvention, startDate, repayDate)
+	return principal.Mul(calculateDaysInterestRate(interestRate, getDaysOfYearOfConvention(dayCountConvention, daysOfYear))).
+		Mul(decimal.NewFromInt(days)), days
+}
+
+// 按计息基准计算 startDate 到 repayDate(不含)的计息天数
+func getDaysOfPeriod(dayCountConvention DayCountConvention, startDate, repayDate time.Time) int64 {
+	switch dayCountConvention {
+	case DayCount30360, DayCount30E360:
+		return getDaysOf30360(startDate, repayDate, dayCountConvention == DayCount30E360)
+	}
+	return getDaysBetweenDate(startDate, repayDate.AddDate(0, 0, -1))
+}
+
+// ACT/ACT ISDA:按自然年拆分,闰年部分除以366,平年部分除以365
+func calculateInterestOfActActISDA(principal, interestRate decimal.Decimal, startDate, repayDate time.Time) (decimal.Decimal, int64) {
+	interest := decimal.Zero
+	var totalDays int64
+	for segmentStartDate := startDate; segmentStartDate.Before(repayDate); {
+		nextYear := time.Date(segmentStartDate.Year()+1, time.January, 1, 0, 0, 0, 0, segmentStartDate.Location())
+		segmentEndDate := repayDate
+		if nextYear.Before(repayDate) {
+			segmentEndDate = nextYear
+		}
+		days := getDaysBetweenDate(segmentStartDate, segmentEndDate.AddDate(0, 0, -1))
+		interest = interest.Add(principal.Mul(calculateDaysInterestRate(interestRate, getDaysOfYear(segmentStartDate.Year()))).
+			Mul(decimal.NewFromInt(days)))
+		totalDays += days
+		segmentStartDate = segmentEndDate
+	}
+	return interest, totalDays
+}
+
+// 30/360 计息天数,eurobond 为 true 时按 30E/360
+func getDaysOf30360(startDate, endDate time.Time, eurobond bool) int64 {
+	d1, d2 := startDate.Day(), endDate.Day()
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && (eurobond || d1 == 30) {
+		d2 = 30
+	}
+	return int64(360*(endDate.Year()-startDate.Year()) + 30*(int(endDate.Month())-int(startDate.Month())) + d2 - d1)
+}
+
+func getDaysOfYear(year int) int {
+	if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		return 366
+	}
+	return 365
+}
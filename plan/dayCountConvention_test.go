@@ -0,0 +1,93 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+)
+
+func Test_getDaysOfPeriod(t *testing.T) {
+	cases := []struct {
+		convention DayCountConvention
+		start, end string
+		want       int64
+	}{
+		{DayCountAct360, "2022-01-31", "2022-03-01", 29},
+		{DayCount30360, "2022-01-31", "2022-03-01", 31},
+		{DayCount30360, "2022-01-30", "2022-03-31", 60},
+		{DayCount30360, "2022-01-15", "2022-03-31", 76},
+		{DayCount30E360, "2022-01-15", "2022-03-31", 75},
+		{DayCount30E360, "2022-02-28", "2022-03-31", 32},
+	}
+	for _, c := range cases {
+		start, _ := time.ParseInLocation(DATE_DASH_FORMAT, c.start, time.Local)
+		end, _ := time.ParseInLocation(DATE_DASH_FORMAT, c.end, time.Local)
+		if got := getDaysOfPeriod(c.convention, start, end); got != c.want {
+			t.Errorf("%s %s-%s: got %d days, want %d", c.convention, c.start, c.end, got, c.want)
+		}
+	}
+}
+
+func Test_calculateInterestOfActActISDA(t *testing.T) {
+	start, _ := time.ParseInLocation(DATE_DASH_FORMAT, "2023-12-01", time.Local)
+	end, _ := time.ParseInLocation(DATE_DASH_FORMAT, "2024-02-01", time.Local)
+	// 2023年31天/365 + 2024年31天/366
+	interest, days := calculateInterest(decimal.NewFromInt(1000000), decimal.NewFromInt(5), DayCountActActISDA, 360, start, end)
+	if days != 62 {
+		t.Errorf("got %d days, want 62", days)
+	}
+	if want := decimal.RequireFromString("8481.55"); !interest.Round(2).Equal(want) {
+		t.Errorf("got interest %s, want %s", interest.Round(2), want)
+	}
+}
+
+/**
+  *@Description 先息后本 30/360 每期利息相同
+**/
+func Test_dayCountConvention30360(t *testing.T) {
+	request := &Request{
+		LoanAmount:         decimal.NewFromFloat(360000),
+		LoanStartDate:      "2022-01-15",
+		InterestRate:       decimal.NewFromFloat(6),
+		PeriodNum:          6,
+		RepayDay:           15,
+		LoanCycleCode:      LoanCycleMonthly,
+		RepayMethod:        BeforeInterestAfterPrincipal,
+		PeriodType:         PeriodTypeMonth,
+		DayCountConvention: DayCount30360,
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range resp.PlanRepayRecords {
+		if item.DaysOfPeriod != 30 || !item.PeriodRepayInterest.Equal(decimal.NewFromInt(1800)) || item.DayCountConvention != DayCount30360 {
+			t.Errorf("period %d: %d days interest %s convention %s", item.PeriodNum, item.DaysOfPeriod, item.PeriodRepayInterest, item.DayCountConvention)
+		}
+	}
+}
+
+/**
+  *@Description 等额本息 按日还款 ACT/365F 期利率按365天计算 最后一期与其他各期还款金额只差舍入尾差
+**/
+func Test_dayCountConventionAct365FDaily(t *testing.T) {
+	request := &Request{
+		LoanAmount:         decimal.NewFromFloat(100000),
+		LoanStartDate:      "2022-01-01",
+		InterestRate:       decimal.NewFromFloat(10),
+		PeriodNum:          30,
+		LoanCycleCode:      LoanCycleDaily,
+		RepayMethod:        EqualLoanRepayment,
+		PeriodType:         PeriodTypeMonth,
+		DayCountConvention: DayCountAct365F,
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := resp.PlanRepayRecords
+	residual := records[29].PeriodRepayTotalAmount.Sub(records[0].PeriodRepayTotalAmount).Abs()
+	if resp.DaysOfYear != 365 || residual.GreaterThan(decimal.RequireFromString("0.1")) {
+		t.Errorf("got days of year %d installment %s final %s", resp.DaysOfYear, records[0].PeriodRepayTotalAmount, records[29].PeriodRepayTotalAmount)
+	}
+}
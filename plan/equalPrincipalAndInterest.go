@@ -1,4 +1,4 @@
-package main
+package plan
 
 import (
 	"github.com/shopspring/decimal"
@@ -14,22 +14,20 @@ func equalPrincipalAndInterestPlan(request repayPlanRequest, response *Response)
 
 	records := make([]RepayPlanRecord, 0)
 
-	planRepayPrinciplePeriod := request.LoanAmount.Div(decimal.NewFromInt(int64(request.TotalPeriodNum))).Round(2)
-
-	totalCalcDays := getDaysBetweenDate(request.LoanStartDateParseLocal, request.LoanEndDateParseLocal)
+	// 每期还款本金,尾差按舍入规则分摊
+	planRepayPrinciples := splitAmount(request.LoanAmount, request.TotalPeriodNum, request.RoundingPolicy)
 
 	// 2.3 everyMonth need to repay interest amount = LoanAmount*daysRate*totalDays/periodNum
-	planRepayInterestPeriod := request.LoanAmount.Mul(request.DaysInterestRate).
-		Mul(decimal.NewFromInt(totalCalcDays)).Div(decimal.NewFromInt(int64(request.TotalPeriodNum))).Round(2)
+	planRepayInterests := splitAmount(calculateAddOnInterest(request), request.TotalPeriodNum, request.RoundingPolicy)
 
-	dateMap := calculatePeriodDate(request)
+	dateMap := request.PeriodDates
 
 	for i := 0; i < request.TotalPeriodNum; i++ {
 		periodStartDate := dateMap[i][0]
 		periodEndDate := dateMap[i][1]
 		periodRepayDate := dateMap[i][2]
 
-		daysOfPeriod := getDaysBetweenDate(periodStartDate, periodEndDate)
+		daysOfPeriod := getDaysOfPeriod(request.DayCountConvention, periodStartDate, periodEndDate.AddDate(0, 0, 1))
 
 		record := RepayPlanRecord{
 			PeriodNum:           i + 1,                                    // 当前期次的期数
@@ -37,20 +35,14 @@ func equalPrincipalAndInterestPlan(request repayPlanRequest, response *Response)
 			PeriodEndDate:       periodEndDate.Format(DATE_DASH_FORMAT),   // 当前期次的结束计息日
 			PeriodRepayDate:     periodRepayDate.Format(DATE_DASH_FORMAT), // 当前期次的还款日
 			DaysOfPeriod:        int(daysOfPeriod),
-			PeriodRepayInterest: planRepayInterestPeriod,
+			PeriodRepayInterest: planRepayInterests[i],
+			DayCountConvention:  request.DayCountConvention,
+			InterestRate:        getInterestRateOf(request, periodEndDate),
 		}
 
-		if i == request.TotalPeriodNum-1 {
-			remainPrinciple := request.LoanAmount.Sub(hasRepayPrincipal)
-			hasRepayPrincipal = hasRepayPrincipal.Add(remainPrinciple)
-			record.PeriodRepayPrinciple = remainPrinciple
-			record.PeriodRepayTotalAmount = planRepayInterestPeriod.Add(remainPrinciple)
-			record.MaintainPrinciple = request.LoanAmount.Sub(hasRepayPrincipal)
-		} else {
-			hasRepayPrincipal = hasRepayPrincipal.Add(planRepayPrinciplePeriod)
-			record.PeriodRepayPrinciple = planRepayPrinciplePeriod
-			record.PeriodRepayTotalAmount = planRepayInterestPeriod.Add(planRepayPrinciplePeriod)
-		}
+		hasRepayPrincipal = hasRepayPrincipal.Add(planRepayPrinciples[i])
+		record.PeriodRepayPrinciple = planRepayPrinciples[i]
+		record.PeriodRepayTotalAmount = planRepayInterests[i].Add(planRepayPrinciples[i])
 
 		record.MaintainPrinciple = request.LoanAmount.Sub(hasRepayPrincipal) // 剩余还款本金
 
@@ -67,3 +59,9 @@ func equalPrincipalAndInterestPlan(request repayPlanRequest, response *Response)
 
 	return nil
 }
+
+// 按贷款本金和贷款期限计算的总利息,总计息天数包含到期日当天
+func calculateAddOnInterest(request repayPlanRequest) decimal.Decimal {
+	totalInterest, _ := calculatePeriodInterest(request, request.LoanAmount, request.LoanStartDateParseLocal, request.LoanEndDateParseLocal.AddDate(0, 0, 1))
+	return roundAmount(totalInterest, request.RoundingPolicy)
+}
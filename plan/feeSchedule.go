@@ -0,0 +1,163 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"strconv"
+)
+
+// FeeChargeType 费用的收取方式
+type FeeChargeType string
+
+// 费用的收取方式
+const (
+	FeeChargeUpfront  FeeChargeType = "1" // 放款时收取
+	FeeChargePeriodic FeeChargeType = "2" // 每期收取
+	FeeChargeSelected FeeChargeType = "3" // 指定期次收取
+)
+
+// FeeBasis 费用的计算方式
+type FeeBasis string
+
+// 费用的计算方式
+const (
+	FeeBasisFixed     FeeBasis = "1" // 固定金额
+	FeeBasisPrincipal FeeBasis = "2" // 贷款金额×费率
+	FeeBasisBalance   FeeBasis = "3" // 期初剩余本金×费率,放款时收取的按贷款金额
+)
+
+// FeeSchedule 贷款的一项费用,如手续费、账户管理费、保险费
+type FeeSchedule struct {
+	Name       string          `json:"name"`       // 费用名称
+	ChargeType FeeChargeType   `json:"chargeType"` // 收取方式 1-放款时收取 2-每期收取 3-指定期次收取
+	Basis      FeeBasis        `json:"basis"`      // 计算方式 1-固定金额 2-贷款金额×费率 3-期初剩余本金×费率
+	Amount     decimal.Decimal `json:"amount"`     // 固定金额
+	Rate       decimal.Decimal `json:"rate"`       // 费率(%)
+	Periods    []int           `json:"periods"`    // 收取的期次 指定期次收取时必填
+	Financed   bool            `json:"financed"`   // 是否计入贷款本金分期偿还 仅放款时收取的费用,否则放款时以现金支付
+}
+
+// PeriodFee 一期中收取的一项费用
+type PeriodFee struct {
+	Name   string          `json:"name"`   // 费用名称
+	Amount decimal.Decimal `json:"amount"` // 金额
+}
+
+func checkFeeSchedules(feeSchedules []FeeSchedule) error {
+	var errs ValidationErrors
+	for i, fee := range feeSchedules {
+		field := "feeSchedules[" + strconv.Itoa(i) + "]."
+		switch fee.ChargeType {
+		case FeeChargeUpfront, FeeChargePeriodic:
+		case FeeChargeSelected:
+			if len(fee.Periods) == 0 {
+				errs.add(field+"periods", CodeRequired, "fee Periods can not be empty")
+			}
+			for _, period := range fee.Periods {
+				if period < 1 {
+					errs.add(field+"periods", CodeInvalid, "fee Periods error")
+					break
+				}
+			}
+		default:
+			errs.add(field+"chargeType", CodeInvalid, "fee Charge Type error")
+		}
+		switch fee.Basis {
+		case FeeBasisFixed:
+			if fee.Amount.LessThanOrEqual(decimal.Zero) {
+				errs.add(field+"amount", CodeInvalid, "fee Amount error")
+			}
+		case FeeBasisPrincipal, FeeBasisBalance:
+			if fee.Rate.LessThanOrEqual(decimal.Zero) {
+				errs.add(field+"rate", CodeInvalid, "fee Rate error")
+			}
+		default:
+			errs.add(field+"basis", CodeInvalid, "fee Basis error")
+		}
+		if fee.Financed && fee.ChargeType != FeeChargeUpfront {
+			errs.add(field+"financed", CodeUnsupported, "fee Financed error: only upfront fee can be financed")
+		}
+	}
+	return errs.err()
+}
+
+// 放款时收取的费用:现金支付的和计入贷款本金的
+func getUpfrontFees(request *Request) (cash, financed decimal.Decimal) {
+	for _, fee := range request.FeeSchedules {
+		if fee.ChargeType != FeeChargeUpfront {
+			continue
+		}
+		amount := roundAmount(getFeeAmount(fee, request.LoanAmount, request.LoanAmount), request.RoundingPolicy)
+		if fee.Financed {
+			financed = financed.Add(amount)
+		} else {
+			cash = cash.Add(amount)
+		}
+	}
+	return cash, financed
+}
+
+func getFeeAmount(fee FeeSchedule, loanAmount, balance decimal.Decimal) decimal.Decimal {
+	switch fee.Basis {
+	case FeeBasisPrincipal:
+		return loanAmount.Mul(fee.Rate).Div(decimal.NewFromInt(100))
+	case FeeBasisBalance:
+		return balance.Mul(fee.Rate).Div(decimal.NewFromInt(100))
+	}
+	return fee.Amount
+}
+
+func isFeeChargedIn(fee FeeSchedule, periodNum int) bool {
+	switch fee.ChargeType {
+	case FeeChargePeriodic:
+		return true
+	case FeeChargeSelected:
+		for _, period := range fee.Periods {
+			if period == periodNum {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/**
+  *@Description 每期收取和指定期次收取的费用计入各期的还款总金额，期初剩余本金为上一期的剩余还款金额，第一期为 startBalance
+**/
+func applyPeriodFees(records []RepayPlanRecord, feeSchedules []FeeSchedule, loanAmount, startBalance decimal.Decimal, policy RoundingPolicy) {
+	balance := startBalance
+	for i := range records {
+		record := &records[i]
+		for _, fee := range feeSchedules {
+			if !isFeeChargedIn(fee, record.PeriodNum) {
+				continue
+			}
+			amount := roundAmount(getFeeAmount(fee, loanAmount, balance), policy)
+			record.Fees = append(record.Fees, PeriodFee{Name: fee.Name, Amount: amount})
+			record.PeriodRepayFee = record.PeriodRepayFee.Add(amount)
+			record.PeriodRepayTotalAmount = record.PeriodRepayTotalAmount.Add(amount)
+		}
+		balance = record.MaintainPrinciple
+	}
+}
+
+// 费用合计=放款时收取的费用+各期费用
+func getTotalFees(response *Response) decimal.Decimal {
+	totalFees := response.UpfrontFee
+	for _, record := range response.PlanRepayRecords {
+		totalFees = totalFees.Add(record.PeriodRepayFee)
+	}
+	return totalFees
+}
+
+/**
+  *@Description 按费用计划填充各期费用、放款时收取的费用和费用合计，计入本金的费用从放款金额中扣除
+**/
+func fillFees(request *Request, response *Response, loanAmount, cashFee, financedFee decimal.Decimal) {
+	response.FeeSchedules = request.FeeSchedules
+	response.FinancedFee = financedFee
+	// 计入本金的费用视为放款时从贷款本金中扣除,计算实际年化利率时实际放款金额为贷款金额减去全部放款时收取的费用
+	response.UpfrontFee = request.UpfrontFee.Add(cashFee).Add(financedFee)
+	applyPeriodFees(response.PlanRepayRecords, request.FeeSchedules, loanAmount, response.LoanAmount, response.RoundingPolicy)
+	fillRepayPlanRecords(response, response.PlanRepayRecords)
+	response.TotalFees = getTotalFees(response)
+}
@@ -0,0 +1,201 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+/**
+  *@Description 放款时收取的手续费、每期的账户管理费和指定期次按剩余本金收取的保险费
+**/
+func Test_feeSchedules(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   EqualPrincipalRepayment,
+		PeriodType:    PeriodTypeMonth,
+	}
+	base, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.FeeSchedules = []FeeSchedule{
+		{Name: "手续费", ChargeType: FeeChargeUpfront, Basis: FeeBasisPrincipal, Rate: decimal.NewFromInt(1)},
+		{Name: "账户管理费", ChargeType: FeeChargePeriodic, Basis: FeeBasisFixed, Amount: decimal.NewFromInt(10)},
+		{Name: "保险费", ChargeType: FeeChargeSelected, Basis: FeeBasisBalance, Rate: decimal.RequireFromString("0.5"), Periods: []int{1, 7}},
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := resp.PlanRepayRecords
+	want := map[int]string{0: "610", 1: "10", 6: "310", 11: "10"}
+	for i, fee := range want {
+		if !records[i].PeriodRepayFee.Equal(decimal.RequireFromString(fee)) {
+			t.Errorf("period %d fee got %s, want %s", i+1, records[i].PeriodRepayFee, fee)
+		}
+		if !records[i].PeriodRepayTotalAmount.Equal(base.PlanRepayRecords[i].PeriodRepayTotalAmount.Add(records[i].PeriodRepayFee)) {
+			t.Errorf("period %d total amount %s does not include fee", i+1, records[i].PeriodRepayTotalAmount)
+		}
+	}
+	if len(records[0].Fees) != 2 || records[0].Fees[1].Name != "保险费" || !records[0].Fees[1].Amount.Equal(decimal.NewFromInt(600)) {
+		t.Errorf("unexpected fees %+v", records[0].Fees)
+	}
+	if !resp.UpfrontFee.Equal(decimal.NewFromInt(1200)) || !resp.TotalFees.Equal(decimal.NewFromInt(2220)) {
+		t.Errorf("got upfront fee %s total fees %s, want 1200 2220", resp.UpfrontFee, resp.TotalFees)
+	}
+	if !resp.TotalRepayAmount.Equal(base.TotalRepayAmount.Add(decimal.NewFromInt(1020))) || !resp.TotalInterest.Equal(base.TotalInterest) {
+		t.Errorf("got total repay amount %s interest %s", resp.TotalRepayAmount, resp.TotalInterest)
+	}
+	if resp.AnnualPercentageRate.LessThanOrEqual(base.AnnualPercentageRate) {
+		t.Errorf("APR %s not greater than %s without fees", resp.AnnualPercentageRate, base.AnnualPercentageRate)
+	}
+}
+
+/**
+  *@Description 计入本金的手续费与贷款金额一起分期偿还,实际放款金额不变
+**/
+func Test_feeSchedulesFinanced(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   EqualLoanRepayment,
+		PeriodType:    PeriodTypeMonth,
+		FeeSchedules: []FeeSchedule{
+			{Name: "手续费", ChargeType: FeeChargeUpfront, Basis: FeeBasisPrincipal, Rate: decimal.NewFromInt(2), Financed: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.LoanAmount.Equal(decimal.NewFromInt(122400)) || !resp.FinancedFee.Equal(decimal.NewFromInt(2400)) {
+		t.Errorf("got loan amount %s financed fee %s, want 122400 2400", resp.LoanAmount, resp.FinancedFee)
+	}
+	if !resp.UpfrontFee.Equal(decimal.NewFromInt(2400)) || !resp.TotalFees.Equal(decimal.NewFromInt(2400)) {
+		t.Errorf("got upfront fee %s total fees %s, want 2400", resp.UpfrontFee, resp.TotalFees)
+	}
+	if !resp.PlanRepayRecords[0].PeriodRepayFee.IsZero() {
+		t.Errorf("financed fee charged in period 1: %s", resp.PlanRepayRecords[0].PeriodRepayFee)
+	}
+	if resp.AnnualPercentageRate.LessThanOrEqual(resp.InterestRate) {
+		t.Errorf("APR %s not greater than interest rate %s", resp.AnnualPercentageRate, resp.InterestRate)
+	}
+}
+
+/**
+  *@Description 部分提前还款后重新生成的期次按新的期次收取费用,月供不含费用
+**/
+func Test_feeSchedulesPrepayment(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   EqualLoanRepayment,
+		PeriodType:    PeriodTypeMonth,
+		FeeSchedules: []FeeSchedule{
+			{Name: "账户管理费", ChargeType: FeeChargePeriodic, Basis: FeeBasisFixed, Amount: decimal.NewFromInt(10)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prepayResp, err := CalculatePrepaymentPlan(resp, &PrepaymentRequest{
+		PrepayDate:   "2022-04-15",
+		PrepayAmount: decimal.NewFromInt(30000),
+		Strategy:     PrepayShortenTerm,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := prepayResp.PlanRepayRecords
+	if !records[3].PeriodRepayFee.IsZero() {
+		t.Errorf("prepayment record charged fee %s", records[3].PeriodRepayFee)
+	}
+	if !records[4].PeriodRepayFee.Equal(decimal.NewFromInt(10)) || !records[4].PeriodRepayTotalAmount.Equal(resp.PlanRepayRecords[0].PeriodRepayTotalAmount) {
+		t.Errorf("unexpected period after prepayment %+v", records[4])
+	}
+	if prepayResp.TotalPeriodNum != 10 || !prepayResp.TotalFees.Equal(decimal.NewFromInt(90)) {
+		t.Errorf("got %d periods total fees %s, want 10 periods 90", prepayResp.TotalPeriodNum, prepayResp.TotalFees)
+	}
+}
+
+/**
+  *@Description 还款入账时各期费用在还款日应收
+**/
+func Test_feeSchedulesLedger(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   EqualLoanRepayment,
+		PeriodType:    PeriodTypeMonth,
+		FeeSchedules: []FeeSchedule{
+			{Name: "账户管理费", ChargeType: FeeChargePeriodic, Basis: FeeBasisFixed, Amount: decimal.NewFromInt(10)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger, err := CalculateLedger(resp, &LedgerRequest{
+		AsOfDate: "2022-03-01",
+		Payments: []Payment{{PaymentDate: "2022-02-01", Amount: resp.PlanRepayRecords[0].PeriodRepayTotalAmount}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ledger.LedgerRecords[0].Status != PeriodPaid || !ledger.OutstandingFee.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("got period 1 status %s outstanding fee %s, want paid 10", ledger.LedgerRecords[0].Status, ledger.OutstandingFee)
+	}
+}
+
+func Test_checkFeeSchedules(t *testing.T) {
+	_, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   EqualLoanRepayment,
+		PeriodType:    PeriodTypeMonth,
+		FeeSchedules: []FeeSchedule{
+			{Name: "账户管理费", ChargeType: FeeChargePeriodic, Basis: FeeBasisFixed, Amount: decimal.NewFromInt(10), Financed: true},
+			{Name: "保险费", ChargeType: FeeChargeSelected, Basis: "4"},
+		},
+	})
+	var validationErrors ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("got %v, want ValidationErrors", err)
+	}
+	want := []struct {
+		field string
+		code  ErrorCode
+	}{
+		{"feeSchedules[0].financed", CodeUnsupported},
+		{"feeSchedules[1].periods", CodeRequired},
+		{"feeSchedules[1].basis", CodeInvalid},
+	}
+	if len(validationErrors) != len(want) {
+		t.Fatalf("got %v", validationErrors)
+	}
+	for i, w := range want {
+		if validationErrors[i].Field != w.field || validationErrors[i].Code != w.code {
+			t.Errorf("error %d: got %+v, want %s %s", i, validationErrors[i], w.field, w.code)
+		}
+	}
+}
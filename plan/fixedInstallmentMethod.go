@@ -1,10 +1,11 @@
-package main
+package plan
 
 import (
 	"errors"
 	"github.com/shopspring/decimal"
 	"math"
 	"strconv"
+	"time"
 )
 
 /*
@@ -19,28 +20,65 @@ import (
 *
 */
 func fixedInstallmentMethodPlan(request repayPlanRequest, response *Response) error {
+	records, err := calculateRecordsWithGracePeriod(request, calculateFixedInstallmentAmortizeRecords)
+	if err != nil {
+		return err
+	}
+	fillRepayPlanRecords(response, records)
 
-	var sumTotalInterest, hasRepayPrincipal, sumTotalRepayAmount decimal.Decimal
-	records := make([]RepayPlanRecord, 0)
+	return nil
+}
 
+// 等额本息摊还各期
+func calculateFixedInstallmentAmortizeRecords(request repayPlanRequest, dateMap map[int][]time.Time) ([]RepayPlanRecord, error) {
 	// 每期总还款金额(本金+利息)
-	everyPeriodRepayAmount, err := calculateFixedInstallmentMethod(request.LoanAmount, request.PeriodInterestRate, request.TotalPeriodNum)
+	everyPeriodRepayAmount, err := calculateFixedInstallmentMethod(request.LoanAmount, request.PeriodInterestRate, request.TotalPeriodNum, request.RoundingPolicy)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return calculateFixedInstallmentPlanRecords(request, dateMap, everyPeriodRepayAmount)
+}
 
-	dateMap := calculatePeriodDate(request)
+// 按每期还款金额生成还款计划,并按尾差处理方式调整尾差
+func calculateFixedInstallmentPlanRecords(request repayPlanRequest, dateMap map[int][]time.Time, everyPeriodRepayAmount decimal.Decimal) ([]RepayPlanRecord, error) {
+	records, err := calculateFixedInstallmentRecords(request, dateMap, everyPeriodRepayAmount, make([]decimal.Decimal, request.TotalPeriodNum))
+	if err != nil {
+		return nil, err
+	}
+	// 尾差不放在最后一期时,调整前面期次的本金使最后一期与其他期次还款金额一致;利率调整后每期还款金额会变化,尾差留在最后一期
+	if request.RoundingPolicy.Residual != ResidualLast && request.TotalPeriodNum > 1 && len(request.RateCurve) == 0 {
+		return adjustFixedInstallmentResidual(request, dateMap, everyPeriodRepayAmount, records)
+	}
+	return records, nil
+}
+
+// 按每期还款金额生成各期还款计划,adjustments 为非最后一期的本金尾差调整金额
+func calculateFixedInstallmentRecords(request repayPlanRequest, dateMap map[int][]time.Time,
+	everyPeriodRepayAmount decimal.Decimal, adjustments []decimal.Decimal) ([]RepayPlanRecord, error) {
+	var hasRepayPrincipal decimal.Decimal
+	records := make([]RepayPlanRecord, 0)
+	installmentInterestRate := getInterestRateOf(request, dateMap[0][0])
 
 	for i := 0; i < request.TotalPeriodNum; i++ {
 		periodStartDate := dateMap[i][0]
 		periodEndDate := dateMap[i][1]
 		periodRepayDate := dateMap[i][2]
 
-		// 当前期次的计息天数
-		daysOfPeriod := getDaysBetweenDate(periodStartDate, periodEndDate)
+		// 利率调整后的第一期,按剩余本金、剩余期数和调整后的利率重新计算每期还款金额
+		if interestRate := getInterestRateOf(request, periodStartDate); i > 0 && !interestRate.Equal(installmentInterestRate) {
+			installmentInterestRate = interestRate
+			periodInterestRate := calculatePeriodInterestRate(interestRate, request.LoanCycleCode, request.DaysOfYear)
+			amount, err := calculateBalloonInstallmentMethod(request.LoanAmount.Sub(hasRepayPrincipal), request.BalloonAmount,
+				periodInterestRate, request.TotalPeriodNum-i, request.RoundingPolicy)
+			if err != nil {
+				return nil, err
+			}
+			everyPeriodRepayAmount = amount
+		}
 
-		// 当前期次的利息=当前剩余本金*计息天数*日利息
-		periodRepayInterest := (request.LoanAmount.Sub(hasRepayPrincipal)).Mul(request.DaysInterestRate).Mul(decimal.NewFromInt(daysOfPeriod)).RoundBank(2)
+		// 当前期次的利息=当前剩余本金*计息天数*日利息,计息天数和日利息按计息基准计算
+		interest, daysOfPeriod := calculatePeriodInterest(request, request.LoanAmount.Sub(hasRepayPrincipal), periodStartDate, periodEndDate.AddDate(0, 0, 1))
+		periodRepayInterest := roundAmount(interest, request.RoundingPolicy)
 
 		record := RepayPlanRecord{
 			PeriodNum:           i + 1,                                    // 当前期次的期数
@@ -49,45 +87,74 @@ func fixedInstallmentMethodPlan(request repayPlanRequest, response *Response) er
 			PeriodRepayDate:     periodRepayDate.Format(DATE_DASH_FORMAT), // 当前期次的还款日
 			DaysOfPeriod:        int(daysOfPeriod),                        // 当前期次的计息天数
 			PeriodRepayInterest: periodRepayInterest,                      // 当前期次的利息
+			DayCountConvention:  request.DayCountConvention,
+			InterestRate:        getInterestRateOf(request, periodEndDate), // 当前期次的年利率
 		}
 
 		// if this is the last period 如果是最后一期
 		if i == request.TotalPeriodNum-1 {
 			remainPrinciple := request.LoanAmount.Sub(hasRepayPrincipal)
-			record.PeriodRepayPrinciple = remainPrinciple.RoundBank(2)               // 当前期次还款本金=上一期总的剩余还款本金
-			record.PeriodRepayTotalAmount = periodRepayInterest.Add(remainPrinciple) // 当前期次的总还款金额=当前期次的利息金额+当前期次还款本金
-			hasRepayPrincipal = hasRepayPrincipal.Add(remainPrinciple)               // 累积已还本金=累积已还本金+上一期总的剩余还款本金
+			record.PeriodRepayPrinciple = roundAmount(remainPrinciple, request.RoundingPolicy) // 当前期次还款本金=上一期总的剩余还款本金
+			record.PeriodRepayTotalAmount = periodRepayInterest.Add(remainPrinciple)           // 当前期次的总还款金额=当前期次的利息金额+当前期次还款本金
+			hasRepayPrincipal = hasRepayPrincipal.Add(remainPrinciple)                         // 累积已还本金=累积已还本金+上一期总的剩余还款本金
 		} else {
 			// if this not the last period 非最后一期
-			periodRepayPrinciple := everyPeriodRepayAmount.Sub(periodRepayInterest) // 当前期次还款本金
-			hasRepayPrincipal = hasRepayPrincipal.Add(periodRepayPrinciple)         // 累积已还本金
-			record.PeriodRepayPrinciple = periodRepayPrinciple.Round(2)             // 当前期次还款本金
-			record.PeriodRepayTotalAmount = everyPeriodRepayAmount                  // 当前期次的总还款金额(除了最后一期，其他期次一样的金额)
+			periodRepayAmount := everyPeriodRepayAmount.Add(adjustments[i])                         // 当前期次的总还款金额(除了尾差调整，其他期次一样的金额)
+			periodRepayPrinciple := periodRepayAmount.Sub(periodRepayInterest)                      // 当前期次还款本金
+			hasRepayPrincipal = hasRepayPrincipal.Add(periodRepayPrinciple)                         // 累积已还本金
+			record.PeriodRepayPrinciple = roundAmount(periodRepayPrinciple, request.RoundingPolicy) // 当前期次还款本金
+			record.PeriodRepayTotalAmount = periodRepayAmount
 		}
 
 		record.MaintainPrinciple = request.LoanAmount.Sub(hasRepayPrincipal) // 剩余还款本金
 
-		// 累积还款总金额
-		sumTotalRepayAmount = sumTotalRepayAmount.Add(record.PeriodRepayTotalAmount)
-		// 累积还款总利息
-		sumTotalInterest = sumTotalInterest.Add(record.PeriodRepayInterest)
-
 		records = append(records, record)
 	}
+	return records, nil
+}
 
-	response.PlanRepayRecords = records
-	response.TotalRepayAmount = sumTotalRepayAmount
-	response.TotalInterest = sumTotalInterest
+// 尾差放在第一期或分摊到各期时,用割线法求前面期次的本金调整金额,使最后一期的还款金额等于每期还款金额
+func adjustFixedInstallmentResidual(request repayPlanRequest, dateMap map[int][]time.Time,
+	everyPeriodRepayAmount decimal.Decimal, records []RepayPlanRecord) ([]RepayPlanRecord, error) {
+	// 气球贷最后一期还款金额=每期还款金额+尾款
+	residualOf := func(records []RepayPlanRecord) decimal.Decimal {
+		return records[len(records)-1].PeriodRepayTotalAmount.Sub(everyPeriodRepayAmount).Sub(request.BalloonAmount)
+	}
+	scale := getRoundingScale(request.RoundingPolicy)
+
+	best := records
+	lastAdjustment, lastResidual := decimal.Zero, residualOf(records)
+	adjustment := lastResidual
+	for i := 0; i < maxResidualIterations && !lastResidual.IsZero(); i++ {
+		adjustments := make([]decimal.Decimal, request.TotalPeriodNum)
+		allocateResidual(adjustments[:request.TotalPeriodNum-1], adjustment, request.RoundingPolicy)
+		current, err := calculateFixedInstallmentRecords(request, dateMap, everyPeriodRepayAmount, adjustments)
+		if err != nil {
+			return nil, err
+		}
 
-	return nil
+		residual := residualOf(current)
+		if residual.Abs().LessThan(residualOf(best).Abs()) {
+			best = current
+		}
+		if residual.IsZero() || residual.Equal(lastResidual) {
+			break
+		}
+		nextAdjustment := adjustment.Sub(residual.Mul(adjustment.Sub(lastAdjustment)).Div(residual.Sub(lastResidual))).Round(scale)
+		if nextAdjustment.Equal(adjustment) {
+			break
+		}
+		lastAdjustment, lastResidual, adjustment = adjustment, residual, nextAdjustment
+	}
+	return best, nil
 }
 
 // calculate the repayable amount of Fixed Installment Method
-func calculateFixedInstallmentMethod(loanAmount, periodInterestRate decimal.Decimal, totalPeriodNum int) (decimal.Decimal, error) {
+func calculateFixedInstallmentMethod(loanAmount, periodInterestRate decimal.Decimal, totalPeriodNum int, roundingPolicy RoundingPolicy) (decimal.Decimal, error) {
 	planRepayAmount := decimal.Zero
 	//如果利率为0
 	if periodInterestRate.Equal(decimal.Zero) {
-		planRepayAmount = loanAmount.Div(decimal.NewFromInt(int64(totalPeriodNum))).Round(2)
+		planRepayAmount = roundAmount(loanAmount.Div(decimal.NewFromInt(int64(totalPeriodNum))), roundingPolicy)
 		return planRepayAmount, nil
 	} else {
 		// calculate every month need to repay total amount
@@ -99,8 +166,8 @@ func calculateFixedInstallmentMethod(loanAmount, periodInterestRate decimal.Deci
 		// (1+期利率)^期数
 		pow := math.Pow(periodRateCal, float64(totalPeriodNum))
 		// 每月还款金额=贷款本金*期利率*(1+期利率)^期数/((1+期利率)^期数-1) 保留两位小数
-		planRepayAmount = loanAmount.Mul(periodInterestRate).Mul(decimal.NewFromFloat(pow)).
-			Div(decimal.NewFromFloat(pow).Sub(decimal.NewFromFloat(1))).Round(2)
+		planRepayAmount = roundAmount(loanAmount.Mul(periodInterestRate).Mul(decimal.NewFromFloat(pow)).
+			Div(decimal.NewFromFloat(pow).Sub(decimal.NewFromFloat(1))), roundingPolicy)
 	}
 	return planRepayAmount, nil
 }
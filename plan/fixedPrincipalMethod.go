@@ -1,7 +1,8 @@
-package main
+package plan
 
 import (
 	"github.com/shopspring/decimal"
+	"time"
 )
 
 /**
@@ -10,22 +11,32 @@ import (
   *@Date 2023/12/5 10:18
 **/
 func fixedPrincipalMethodPlan(request repayPlanRequest, response *Response) error {
-	var sumTotalInterest, hasRepayPrincipal, sumTotalRepayAmount decimal.Decimal
-	records := make([]RepayPlanRecord, 0)
+	records, err := calculateRecordsWithGracePeriod(request, func(request repayPlanRequest, dateMap map[int][]time.Time) ([]RepayPlanRecord, error) {
+		// 每期还款本金,尾差按舍入规则分摊
+		periodRepayPrinciples := calculateFixedPrincipalMethod(request.LoanAmount, request.TotalPeriodNum, request.RoundingPolicy)
+		return calculatePrincipalScheduleRecords(request, dateMap, periodRepayPrinciples), nil
+	})
+	if err != nil {
+		return err
+	}
+	fillRepayPlanRecords(response, records)
+
+	return nil
+}
 
-	// 每期还款本金
-	periodRepayPrinciple := calculateFixedPrincipalMethod(request.LoanAmount, request.TotalPeriodNum)
+// 按给定的各期还款本金生成还款计划,每期利息按剩余本金计算
+func calculatePrincipalScheduleRecords(request repayPlanRequest, dateMap map[int][]time.Time, periodRepayPrinciples []decimal.Decimal) []RepayPlanRecord {
+	var hasRepayPrincipal decimal.Decimal
+	records := make([]RepayPlanRecord, 0)
 
-	dateMap := calculatePeriodDate(request)
 	for i := 0; i < request.TotalPeriodNum; i++ {
 		periodStartDate := dateMap[i][0]
 		periodEndDate := dateMap[i][1]
 		periodRepayDate := dateMap[i][2]
 
-		// 当前期次的计息天数
-		daysOfPeriod := getDaysBetweenDate(periodStartDate, periodEndDate)
-
-		periodRepayInterest := (request.LoanAmount.Sub(hasRepayPrincipal)).Mul(request.DaysInterestRate).Mul(decimal.NewFromInt(daysOfPeriod)).RoundBank(2)
+		// 当前期次的利息=当前剩余本金*计息天数*日利息,计息天数和日利息按计息基准计算
+		interest, daysOfPeriod := calculatePeriodInterest(request, request.LoanAmount.Sub(hasRepayPrincipal), periodStartDate, periodEndDate.AddDate(0, 0, 1))
+		periodRepayInterest := roundAmount(interest, request.RoundingPolicy)
 
 		record := RepayPlanRecord{
 			PeriodNum:           i + 1,                                    // current period num 当前期次的期数
@@ -34,35 +45,22 @@ func fixedPrincipalMethodPlan(request repayPlanRequest, response *Response) erro
 			PeriodRepayDate:     periodRepayDate.Format(DATE_DASH_FORMAT), // 当前期次的还款日
 			DaysOfPeriod:        int(daysOfPeriod),                        // 当前期次的计息天数
 			PeriodRepayInterest: periodRepayInterest,                      // 当前期次的利息
+			DayCountConvention:  request.DayCountConvention,
+			InterestRate:        getInterestRateOf(request, periodEndDate), // 当前期次的年利率
 		}
 
-		// if this is the last period 如果是最后一期
-		if i == request.TotalPeriodNum-1 {
-			remainPrinciple := request.LoanAmount.Sub(hasRepayPrincipal)
-			record.PeriodRepayPrinciple = remainPrinciple.RoundBank(2)               // 当前期次还款本金=上一期总的剩余还款本金
-			record.PeriodRepayTotalAmount = periodRepayInterest.Add(remainPrinciple) // 当前期次的总还款金额=当前期次的利息金额+当前期次还款本金
-			hasRepayPrincipal = hasRepayPrincipal.Add(remainPrinciple)               // 累积已还本金=累积已还本金+上一期总的剩余还款本金
-		} else {
-			// if this not the last period 非最后一期
-			hasRepayPrincipal = hasRepayPrincipal.Add(periodRepayPrinciple) // 累积已还本金
-			record.PeriodRepayPrinciple = periodRepayPrinciple              // 当前期次还款本金
-			// 当前期次的总还款金额=当前期次还款本金+当前期次的利息金额
-			record.PeriodRepayTotalAmount = periodRepayInterest.Add(periodRepayPrinciple)
-		}
+		periodRepayPrinciple := periodRepayPrinciples[i]
+		hasRepayPrincipal = hasRepayPrincipal.Add(periodRepayPrinciple) // 累积已还本金
+		record.PeriodRepayPrinciple = periodRepayPrinciple              // 当前期次还款本金
+		// 当前期次的总还款金额=当前期次还款本金+当前期次的利息金额
+		record.PeriodRepayTotalAmount = periodRepayInterest.Add(periodRepayPrinciple)
 		record.MaintainPrinciple = request.LoanAmount.Sub(hasRepayPrincipal) // 剩余还款本金
 
-		// 累积还款总金额
-		sumTotalRepayAmount = sumTotalRepayAmount.Add(record.PeriodRepayTotalAmount)
-		// 累积还款总利息
-		sumTotalInterest = sumTotalInterest.Add(record.PeriodRepayInterest)
 		records = append(records, record)
 	}
-	response.PlanRepayRecords = records
-	response.TotalRepayAmount = sumTotalRepayAmount
-	response.TotalInterest = sumTotalInterest
-
-	return nil
+	return records
 }
-func calculateFixedPrincipalMethod(loanAmount decimal.Decimal, totalPeriodNum int) decimal.Decimal {
-	return loanAmount.Div(decimal.NewFromInt(int64(totalPeriodNum))).RoundBank(2)
+
+func calculateFixedPrincipalMethod(loanAmount decimal.Decimal, totalPeriodNum int, roundingPolicy RoundingPolicy) []decimal.Decimal {
+	return splitAmount(loanAmount, totalPeriodNum, roundingPolicy)
 }
@@ -0,0 +1,163 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"sort"
+	"time"
+)
+
+// RateReset 利率调整:自生效日期起执行新的年利率
+type RateReset struct {
+	EffectiveDate string          `json:"effectiveDate"` // 生效日期
+	InterestRate  decimal.Decimal `json:"interestRate"`  // 年利率
+}
+
+// FloatingRate 浮动利率:基准利率加点,按重定价周期调整
+type FloatingRate struct {
+	BenchmarkRates []RateReset     `json:"benchmarkRates"` // 基准利率(如LPR)的历次调整
+	Margin         decimal.Decimal `json:"margin"`         // 加点(%),可以为负,基准利率加点不能为负
+	ResetMonths    int             `json:"resetMonths"`    // 重定价周期(月) 默认12
+	FirstResetDate string          `json:"firstResetDate"` // 首个重定价日 默认贷款开始日期加一个重定价周期
+}
+
+const defaultResetMonths = 12
+
+// 利率曲线上的点
+type rateCurvePoint struct {
+	EffectiveDate time.Time
+	InterestRate  decimal.Decimal
+}
+
+// 检查利率调整计划和浮动利率参数并填充默认值
+func checkRateSchedule(request *Request) error {
+	var errs ValidationErrors
+	if len(request.RateSchedule) > 0 && request.FloatingRate != nil {
+		errs.add("floatingRate", CodeConflict, "rate Schedule and floating Rate can not be set at the same time")
+	}
+	if _, err := parseRateCurve(request.RateSchedule); err != nil {
+		errs.add("rateSchedule", CodeInvalid, err.Error())
+	}
+	if request.FloatingRate == nil {
+		return errs.err()
+	}
+	if len(request.FloatingRate.BenchmarkRates) == 0 {
+		errs.add("floatingRate.benchmarkRates", CodeRequired, "benchmark Rates can not be empty")
+	}
+	if _, err := parseRateCurve(request.FloatingRate.BenchmarkRates); err != nil {
+		errs.add("floatingRate.benchmarkRates", CodeInvalid, err.Error())
+	}
+	if request.FloatingRate.ResetMonths == 0 {
+		request.FloatingRate.ResetMonths = defaultResetMonths
+	}
+	if request.FloatingRate.ResetMonths < 0 {
+		errs.add("floatingRate.resetMonths", CodeInvalid, "reset Months error")
+	}
+	if request.FloatingRate.FirstResetDate != "" {
+		if _, err := time.ParseInLocation(DATE_DASH_FORMAT, request.FloatingRate.FirstResetDate, time.Local); err != nil {
+			errs.add("floatingRate.firstResetDate", CodeInvalid, "first Reset Date error")
+		}
+	}
+	return errs.err()
+}
+
+// 解析利率调整计划,按生效日期排序
+func parseRateCurve(rateSchedule []RateReset) ([]rateCurvePoint, error) {
+	curve := make([]rateCurvePoint, 0, len(rateSchedule))
+	for _, reset := range rateSchedule {
+		effectiveDate, err := time.ParseInLocation(DATE_DASH_FORMAT, reset.EffectiveDate, time.Local)
+		if err != nil {
+			return nil, errors.New("rate Effective Date error")
+		}
+		if reset.InterestRate.IsNegative() {
+			return nil, errors.New("rate Schedule interest Rate error")
+		}
+		curve = append(curve, rateCurvePoint{EffectiveDate: effectiveDate, InterestRate: reset.InterestRate})
+	}
+	sort.SliceStable(curve, func(i, j int) bool {
+		return curve[i].EffectiveDate.Before(curve[j].EffectiveDate)
+	})
+	return curve, nil
+}
+
+// 贷款期间的利率调整计划:浮动利率在每个重定价日取当日有效的基准利率加点
+func getRateSchedule(request *Request, loanStartDateParseLocal, loanEndDateParseLocal time.Time) ([]RateReset, error) {
+	if request.FloatingRate == nil {
+		return request.RateSchedule, nil
+	}
+	benchmarkCurve, err := parseRateCurve(request.FloatingRate.BenchmarkRates)
+	if err != nil {
+		return nil, err
+	}
+	// 首个重定价日,之后每隔一个重定价周期的同一天重定价
+	firstResetDate := calculateDateAddMonth(loanStartDateParseLocal, request.FloatingRate.ResetMonths, loanStartDateParseLocal.Day())
+	resetDay := loanStartDateParseLocal.Day()
+	if request.FloatingRate.FirstResetDate != "" {
+		firstResetDate, _ = time.ParseInLocation(DATE_DASH_FORMAT, request.FloatingRate.FirstResetDate, time.Local)
+		resetDay = firstResetDate.Day()
+	}
+	rateSchedule := make([]RateReset, 0)
+	for k := 0; ; k++ {
+		resetDate := calculateDateAddMonth(firstResetDate, k*request.FloatingRate.ResetMonths, resetDay)
+		if !resetDate.Before(loanEndDateParseLocal) {
+			break
+		}
+		if benchmarkRate, ok := getCurveRate(benchmarkCurve, resetDate); ok && resetDate.After(loanStartDateParseLocal) {
+			// 负的加点不能使重定价后的利率为负
+			interestRate := benchmarkRate.Add(request.FloatingRate.Margin)
+			if interestRate.IsNegative() {
+				return nil, newValidationError("floatingRate.margin", CodeConflict,
+					"floating interest Rate can not be negative on reset date "+resetDate.Format(DATE_DASH_FORMAT))
+			}
+			rateSchedule = append(rateSchedule, RateReset{
+				EffectiveDate: resetDate.Format(DATE_DASH_FORMAT),
+				InterestRate:  interestRate,
+			})
+		}
+	}
+	return rateSchedule, nil
+}
+
+// 利率曲线在某日有效的利率,曲线在该日之前没有生效的点时返回 false
+func getCurveRate(curve []rateCurvePoint, date time.Time) (decimal.Decimal, bool) {
+	rate, ok := decimal.Zero, false
+	for _, point := range curve {
+		if point.EffectiveDate.After(date) {
+			break
+		}
+		rate, ok = point.InterestRate, true
+	}
+	return rate, ok
+}
+
+// 某日执行的年利率:利率曲线上没有生效的调整时为初始利率
+func getInterestRateOf(request repayPlanRequest, date time.Time) decimal.Decimal {
+	if rate, ok := getCurveRate(request.RateCurve, date); ok {
+		return rate
+	}
+	return request.InterestRate
+}
+
+// 计算本金从 startDate 到 repayDate(不含)的利息(未舍入)和计息天数,期内利率调整时按生效日期拆分计息
+func calculatePeriodInterest(request repayPlanRequest, principal decimal.Decimal, startDate, repayDate time.Time) (decimal.Decimal, int64) {
+	if len(request.RateCurve) == 0 {
+		return calculateInterest(principal, request.InterestRate, request.DayCountConvention, request.DaysOfYear, startDate, repayDate)
+	}
+	interest := decimal.Zero
+	segmentStartDate := startDate
+	for _, point := range request.RateCurve {
+		if !point.EffectiveDate.After(segmentStartDate) {
+			continue
+		}
+		if !point.EffectiveDate.Before(repayDate) {
+			break
+		}
+		segmentInterest, _ := calculateInterest(principal, getInterestRateOf(request, segmentStartDate), request.DayCountConvention,
+			request.DaysOfYear, segmentStartDate, point.EffectiveDate)
+		interest = interest.Add(segmentInterest)
+		segmentStartDate = point.EffectiveDate
+	}
+	segmentInterest, _ := calculateInterest(principal, getInterestRateOf(request, segmentStartDate), request.DayCountConvention,
+		request.DaysOfYear, segmentStartDate, repayDate)
+	return interest.Add(segmentInterest), getDaysOfPeriod(request.DayCountConvention, startDate, repayDate)
+}
@@ -0,0 +1,125 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+/**
+  *@Description 利随本清 期中利率调整 按生效日期拆分计息
+**/
+func Test_rateScheduleSplitInterest(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:    decimal.NewFromFloat(100000),
+		LoanStartDate: "2022-01-01",
+		LoanEndDate:   "2022-03-02",
+		InterestRate:  decimal.NewFromFloat(3.6),
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   BothPrincipalAndInterest,
+		PeriodType:    PeriodTypeMonth,
+		RateSchedule:  []RateReset{{EffectiveDate: "2022-01-31", InterestRate: decimal.NewFromFloat(7.2)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 30天*3.6% + 30天*7.2%,ACT/360
+	if !resp.TotalInterest.Equal(decimal.NewFromInt(900)) || resp.PlanRepayRecords[0].DaysOfPeriod != 60 {
+		t.Errorf("got interest %s days %d, want 900 and 60", resp.TotalInterest, resp.PlanRepayRecords[0].DaysOfPeriod)
+	}
+}
+
+/**
+  *@Description 等额本息 基准利率加点 重定价后按剩余期数重新计算月供
+**/
+func Test_floatingRateReamortize(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(4.9),
+		PeriodNum:     24,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   EqualLoanRepayment,
+		PeriodType:    PeriodTypeMonth,
+		FloatingRate: &FloatingRate{
+			BenchmarkRates: []RateReset{
+				{EffectiveDate: "2021-12-20", InterestRate: decimal.NewFromFloat(4.65)},
+				{EffectiveDate: "2022-08-22", InterestRate: decimal.NewFromFloat(4.3)},
+			},
+			Margin: decimal.NewFromFloat(0.25),
+		},
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.RateSchedule) != 1 || resp.RateSchedule[0].EffectiveDate != "2023-01-01" ||
+		!resp.RateSchedule[0].InterestRate.Equal(decimal.NewFromFloat(4.55)) {
+		t.Fatalf("unexpected rate schedule %+v", resp.RateSchedule)
+	}
+	records := resp.PlanRepayRecords
+	if !records[11].InterestRate.Equal(decimal.NewFromFloat(4.9)) || !records[12].InterestRate.Equal(decimal.NewFromFloat(4.55)) {
+		t.Errorf("got rates %s %s, want 4.9 4.55", records[11].InterestRate, records[12].InterestRate)
+	}
+	if !records[0].PeriodRepayTotalAmount.Equal(records[10].PeriodRepayTotalAmount) ||
+		!records[12].PeriodRepayTotalAmount.LessThan(records[11].PeriodRepayTotalAmount) ||
+		!records[12].PeriodRepayTotalAmount.Equal(records[22].PeriodRepayTotalAmount) {
+		t.Errorf("installment not re-amortized after reset: %s %s %s", records[11].PeriodRepayTotalAmount,
+			records[12].PeriodRepayTotalAmount, records[22].PeriodRepayTotalAmount)
+	}
+	if !records[23].MaintainPrinciple.IsZero() {
+		t.Errorf("maintain principle %s, want 0", records[23].MaintainPrinciple)
+	}
+}
+
+/**
+  *@Description 浮动利率 基准利率加负的加点后为负 重定价时报错
+**/
+func Test_floatingRateNegative(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(1),
+		PeriodNum:     36,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   EqualLoanRepayment,
+		PeriodType:    PeriodTypeMonth,
+		FloatingRate: &FloatingRate{
+			BenchmarkRates: []RateReset{
+				{EffectiveDate: "2021-12-20", InterestRate: decimal.NewFromFloat(1.5)},
+				{EffectiveDate: "2023-06-01", InterestRate: decimal.NewFromFloat(0.2)},
+			},
+			Margin: decimal.NewFromFloat(-0.5),
+		},
+	}
+	_, err := CalculateRepaymentPlan(request)
+	var validationError *ValidationError
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &validationError) || validationError.Field != "floatingRate.margin" {
+		t.Errorf("got %v, want floating rate margin conflict", err)
+	}
+}
+
+func Test_rateScheduleError(t *testing.T) {
+	cases := []*Request{
+		{RateSchedule: []RateReset{{EffectiveDate: "2022/06/01", InterestRate: decimal.NewFromInt(5)}}},
+		{RateSchedule: []RateReset{{EffectiveDate: "2022-06-01", InterestRate: decimal.NewFromInt(-1)}}},
+		{RateSchedule: []RateReset{{EffectiveDate: "2022-06-01", InterestRate: decimal.NewFromInt(5)}}, FloatingRate: &FloatingRate{}},
+		{FloatingRate: &FloatingRate{}},
+	}
+	for _, c := range cases {
+		c.LoanAmount = decimal.NewFromInt(10000)
+		c.LoanStartDate = "2022-01-01"
+		c.InterestRate = decimal.NewFromInt(5)
+		c.PeriodNum = 12
+		c.RepayDay = 1
+		c.LoanCycleCode = LoanCycleMonthly
+		c.RepayMethod = EqualPrincipalRepayment
+		c.PeriodType = PeriodTypeMonth
+		if _, err := CalculateRepaymentPlan(c); err == nil {
+			t.Errorf("expected error for %+v", c)
+		}
+	}
+}
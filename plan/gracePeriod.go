@@ -0,0 +1,109 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// GraceType 宽限期类型
+type GraceType string
+
+// 宽限期类型
+const (
+	GraceInterestOnly GraceType = "1" // 宽限期内只还利息
+	GraceCapitalized  GraceType = "2" // 宽限期内不还款,利息计入本金
+)
+
+func checkGracePeriod(request *Request) error {
+	if request.GracePeriodNum < 0 {
+		return newValidationError("gracePeriodNum", CodeInvalid, "grace Period Num error")
+	}
+	if request.GracePeriodNum == 0 {
+		return nil
+	}
+	switch request.RepayMethod {
+	case EqualLoanRepayment, EqualPrincipalRepayment:
+	default:
+		return newValidationError("gracePeriodNum", CodeUnsupported, "repay method error: grace period not supported")
+	}
+	switch request.GraceType {
+	case "":
+		request.GraceType = GraceInterestOnly
+	case GraceInterestOnly, GraceCapitalized:
+	default:
+		return newValidationError("graceType", CodeInvalid, "grace Type error")
+	}
+	return nil
+}
+
+/**
+  *@Description 宽限期：先生成宽限期的各期，宽限期结束时的剩余本金按 amortize 在剩余期次摊还
+**/
+func calculateRecordsWithGracePeriod(request repayPlanRequest,
+	amortize func(request repayPlanRequest, dateMap map[int][]time.Time) ([]RepayPlanRecord, error)) ([]RepayPlanRecord, error) {
+	dateMap := request.PeriodDates
+	if request.GracePeriodNum == 0 {
+		return amortize(request, dateMap)
+	}
+
+	records, maintainPrinciple := calculateGracePeriodRecords(request, dateMap)
+
+	// 剩余期次从宽限期结束开始摊还,期利率按摊还开始日执行的利率计算
+	amortizeDateMap := make(map[int][]time.Time)
+	for i := request.GracePeriodNum; i < request.TotalPeriodNum; i++ {
+		amortizeDateMap[i-request.GracePeriodNum] = dateMap[i]
+	}
+	amortizeRequest := request
+	amortizeRequest.LoanAmount = maintainPrinciple
+	amortizeRequest.TotalPeriodNum = request.TotalPeriodNum - request.GracePeriodNum
+	amortizeRequest.PeriodDates = amortizeDateMap
+	amortizeRequest.PeriodInterestRate = calculatePeriodInterestRate(getInterestRateOf(request, amortizeDateMap[0][0]),
+		request.LoanCycleCode, request.DaysOfYear)
+	amortizeRecords, err := amortize(amortizeRequest, amortizeDateMap)
+	if err != nil {
+		return nil, err
+	}
+	for i := range amortizeRecords {
+		amortizeRecords[i].PeriodNum += request.GracePeriodNum
+	}
+	return append(records, amortizeRecords...), nil
+}
+
+// 宽限期各期:只还利息时每期归还当期利息,利息计入本金时不还款,返回宽限期结束时的剩余本金
+func calculateGracePeriodRecords(request repayPlanRequest, dateMap map[int][]time.Time) ([]RepayPlanRecord, decimal.Decimal) {
+	maintainPrinciple := request.LoanAmount
+	records := make([]RepayPlanRecord, 0, request.GracePeriodNum)
+	for i := 0; i < request.GracePeriodNum; i++ {
+		periodStartDate := dateMap[i][0]
+		periodEndDate := dateMap[i][1]
+		periodRepayDate := dateMap[i][2]
+
+		interest, daysOfPeriod := calculatePeriodInterest(request, maintainPrinciple, periodStartDate, periodEndDate.AddDate(0, 0, 1))
+		periodInterest := roundAmount(interest, request.RoundingPolicy)
+
+		record := RepayPlanRecord{
+			PeriodNum:            i + 1,
+			PeriodStartDate:      periodStartDate.Format(DATE_DASH_FORMAT),
+			PeriodEndDate:        periodEndDate.Format(DATE_DASH_FORMAT),
+			PeriodRepayDate:      periodRepayDate.Format(DATE_DASH_FORMAT),
+			DaysOfPeriod:         int(daysOfPeriod),
+			PeriodRepayPrinciple: decimal.Zero,
+			DayCountConvention:   request.DayCountConvention,
+			InterestRate:         getInterestRateOf(request, periodEndDate),
+			IsGracePeriod:        true,
+		}
+		if request.GraceType == GraceCapitalized {
+			// 利息计入本金,本期不还款
+			maintainPrinciple = maintainPrinciple.Add(periodInterest)
+			record.CapitalizedInterest = periodInterest
+			record.PeriodRepayInterest = decimal.Zero
+			record.PeriodRepayTotalAmount = decimal.Zero
+		} else {
+			record.PeriodRepayInterest = periodInterest
+			record.PeriodRepayTotalAmount = periodInterest
+		}
+		record.MaintainPrinciple = maintainPrinciple
+		records = append(records, record)
+	}
+	return records, maintainPrinciple
+}
@@ -0,0 +1,116 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+/**
+  *@Description 等额本金 宽限期只还利息 剩余期次摊还
+**/
+func Test_gracePeriodInterestOnly(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:     decimal.NewFromFloat(120000),
+		LoanStartDate:  "2022-01-01",
+		InterestRate:   decimal.NewFromFloat(6),
+		PeriodNum:      12,
+		RepayDay:       1,
+		LoanCycleCode:  LoanCycleMonthly,
+		RepayMethod:    EqualPrincipalRepayment,
+		PeriodType:     PeriodTypeMonth,
+		DaysOfYear:     360,
+		GracePeriodNum: 3,
+		GraceType:      GraceInterestOnly,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := resp.PlanRepayRecords
+	if len(records) != 12 {
+		t.Fatalf("got %d periods, want 12", len(records))
+	}
+	for _, record := range records[:3] {
+		if !record.IsGracePeriod || !record.PeriodRepayPrinciple.IsZero() || !record.MaintainPrinciple.Equal(resp.LoanAmount) ||
+			!record.PeriodRepayTotalAmount.Equal(record.PeriodRepayInterest) {
+			t.Errorf("unexpected grace period %+v", record)
+		}
+	}
+	// 剩余9期每期本金 120000/9
+	if records[3].IsGracePeriod || records[3].PeriodNum != 4 || !records[3].PeriodRepayPrinciple.Equal(decimal.RequireFromString("13333.33")) {
+		t.Errorf("unexpected first amortize period %+v", records[3])
+	}
+	if !records[11].MaintainPrinciple.IsZero() {
+		t.Errorf("maintain principle %s, want 0", records[11].MaintainPrinciple)
+	}
+}
+
+/**
+  *@Description 等额本息 宽限期利息计入本金
+**/
+func Test_gracePeriodCapitalized(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:     decimal.NewFromFloat(120000),
+		LoanStartDate:  "2022-01-01",
+		InterestRate:   decimal.NewFromFloat(6),
+		PeriodNum:      12,
+		RepayDay:       1,
+		LoanCycleCode:  LoanCycleMonthly,
+		RepayMethod:    EqualLoanRepayment,
+		PeriodType:     PeriodTypeMonth,
+		DaysOfYear:     360,
+		GracePeriodNum: 3,
+		GraceType:      GraceCapitalized,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := resp.PlanRepayRecords
+	// 第一期 120000*31天*6%/360=620
+	if !records[0].CapitalizedInterest.Equal(decimal.NewFromInt(620)) || !records[0].PeriodRepayTotalAmount.IsZero() ||
+		!records[0].MaintainPrinciple.Equal(decimal.NewFromInt(120620)) {
+		t.Errorf("unexpected capitalized period %+v", records[0])
+	}
+	capitalized := decimal.Zero
+	for _, record := range records[:3] {
+		capitalized = capitalized.Add(record.CapitalizedInterest)
+	}
+	if !records[2].MaintainPrinciple.Equal(resp.LoanAmount.Add(capitalized)) {
+		t.Errorf("maintain principle %s, want %s", records[2].MaintainPrinciple, resp.LoanAmount.Add(capitalized))
+	}
+	if !records[3].PeriodRepayTotalAmount.Equal(records[10].PeriodRepayTotalAmount) || !records[11].MaintainPrinciple.IsZero() {
+		t.Errorf("unexpected amortize periods %+v %+v", records[3], records[11])
+	}
+	if !resp.TotalRepayAmount.Sub(resp.LoanAmount).Equal(resp.TotalInterest) {
+		t.Errorf("total interest %s, want %s", resp.TotalInterest, resp.TotalRepayAmount.Sub(resp.LoanAmount))
+	}
+}
+
+func Test_gracePeriodError(t *testing.T) {
+	cases := []struct {
+		repayMethod    RepayMethod
+		graceType      GraceType
+		gracePeriodNum int
+	}{
+		{BeforeInterestAfterPrincipal, GraceInterestOnly, 3},
+		{EqualLoanRepayment, "3", 3},
+		{EqualLoanRepayment, GraceInterestOnly, 12},
+	}
+	for _, c := range cases {
+		request := &Request{
+			LoanAmount:     decimal.NewFromFloat(120000),
+			LoanStartDate:  "2022-01-01",
+			InterestRate:   decimal.NewFromFloat(6),
+			PeriodNum:      12,
+			RepayDay:       1,
+			LoanCycleCode:  LoanCycleMonthly,
+			RepayMethod:    c.repayMethod,
+			PeriodType:     PeriodTypeMonth,
+			DaysOfYear:     360,
+			GracePeriodNum: c.gracePeriodNum,
+			GraceType:      c.graceType,
+		}
+		if _, err := CalculateRepaymentPlan(request); err == nil {
+			t.Errorf("expected error for %+v", c)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"math"
+	"strconv"
+	"time"
+)
+
+/**
+  *@Description 阶梯还款(递增或递减)：每隔 StepPeriodNum 期每期还款金额按比例或金额调整一次，求首期还款金额使最后一期还清本金，还款金额不足利息的部分计入本金
+**/
+func graduatedMethodPlan(request repayPlanRequest, response *Response) error {
+	dateMap := request.PeriodDates
+	firstInstallment, err := calculateGraduatedFirstInstallment(request)
+	if err != nil {
+		return err
+	}
+	records, err := solveGraduatedRecords(request, dateMap, firstInstallment)
+	if err != nil {
+		return err
+	}
+	fillRepayPlanRecords(response, records)
+	response.StepPeriodNum = request.StepPeriodNum
+	response.StepRate = request.StepRate
+	response.StepAmount = request.StepAmount
+	return nil
+}
+
+func checkGraduated(request *Request) error {
+	if request.RepayMethod != GraduatedRepayment {
+		if request.StepPeriodNum == 0 && request.StepRate.IsZero() && request.StepAmount.IsZero() {
+			return nil
+		}
+		return newValidationError("stepRate", CodeUnsupported, "repay method error: step only supported by graduated repayment")
+	}
+	var errs ValidationErrors
+	if request.StepRate.IsZero() == request.StepAmount.IsZero() {
+		errs.add("stepRate", CodeConflict, "step Rate and step Amount must be set one of them")
+	}
+	if request.StepRate.LessThanOrEqual(decimal.NewFromInt(-100)) {
+		errs.add("stepRate", CodeInvalid, "step Rate error")
+	}
+	if request.StepPeriodNum < 0 {
+		errs.add("stepPeriodNum", CodeInvalid, "step Period Num error")
+	}
+	if request.StepPeriodNum == 0 {
+		request.StepPeriodNum = getDefaultStepPeriodNum(request)
+	}
+	return errs.err()
+}
+
+// 默认每年调整一次
+func getDefaultStepPeriodNum(request *Request) int {
+	switch request.LoanCycleCode {
+	case LoanCycleDaily:
+		return request.DaysOfYear
+	case LoanCycleFortnightly:
+		return numberOfWeek
+	case LoanCycleQuarterly:
+		return numberOfQuarter
+	case LoanCycleYearly:
+		return 1
+	}
+	return numberOfMonth
+}
+
+// 第 periodIndex 期(从0开始)的还款金额:按比例调整时=首期还款金额×(1+比例)^调整次数,按金额调整时=首期还款金额+金额×调整次数
+func getGraduatedInstallment(request repayPlanRequest, firstInstallment decimal.Decimal, periodIndex int) decimal.Decimal {
+	steps := int64(periodIndex / request.StepPeriodNum)
+	if request.StepAmount.IsZero() {
+		factor := request.StepRate.Div(decimal.NewFromInt(100)).Add(decimal.NewFromInt(1)).Pow(decimal.NewFromInt(steps))
+		return roundAmount(firstInstallment.Mul(factor), request.RoundingPolicy)
+	}
+	return firstInstallment.Add(request.StepAmount.Mul(decimal.NewFromInt(steps)))
+}
+
+// 按期利率估算首期还款金额:贷款金额=Σ第k期还款金额/(1+期利率)^k
+func calculateGraduatedFirstInstallment(request repayPlanRequest) (decimal.Decimal, error) {
+	periodRate, err := strconv.ParseFloat(request.PeriodInterestRate.String(), 64)
+	if err != nil {
+		return decimal.Zero, errors.New("int to float error:" + err.Error())
+	}
+	stepRate, err := strconv.ParseFloat(request.StepRate.Div(decimal.NewFromInt(100)).String(), 64)
+	if err != nil {
+		return decimal.Zero, errors.New("int to float error:" + err.Error())
+	}
+	stepAmount, err := strconv.ParseFloat(request.StepAmount.String(), 64)
+	if err != nil {
+		return decimal.Zero, errors.New("int to float error:" + err.Error())
+	}
+	loanAmount, err := strconv.ParseFloat(request.LoanAmount.String(), 64)
+	if err != nil {
+		return decimal.Zero, errors.New("int to float error:" + err.Error())
+	}
+	// 首期还款金额的现值系数和按金额调整部分的现值
+	var factor, stepPresentValue float64
+	for i := 0; i < request.TotalPeriodNum; i++ {
+		discount := math.Pow(1+periodRate, -float64(i+1))
+		steps := float64(i / request.StepPeriodNum)
+		if request.StepAmount.IsZero() {
+			factor += math.Pow(1+stepRate, steps) * discount
+		} else {
+			factor += discount
+			stepPresentValue += stepAmount * steps * discount
+		}
+	}
+	return roundAmount(decimal.NewFromFloat((loanAmount-stepPresentValue)/factor), request.RoundingPolicy), nil
+}
+
+// 实际按天计息与按期利率估算有差异,用割线法调整首期还款金额,使最后一期的还款金额等于该期的阶梯还款金额
+func solveGraduatedRecords(request repayPlanRequest, dateMap map[int][]time.Time, firstInstallment decimal.Decimal) ([]RepayPlanRecord, error) {
+	lastIndex := request.TotalPeriodNum - 1
+	residualOf := func(records []RepayPlanRecord, firstInstallment decimal.Decimal) decimal.Decimal {
+		return records[lastIndex].PeriodRepayTotalAmount.Sub(getGraduatedInstallment(request, firstInstallment, lastIndex))
+	}
+	scale := getRoundingScale(request.RoundingPolicy)
+
+	best := calculateGraduatedRecords(request, dateMap, firstInstallment)
+	bestResidual := residualOf(best, firstInstallment)
+	bestInstallment := firstInstallment
+	lastInstallment, lastResidual := firstInstallment, bestResidual
+	// 最后一期多还 residual,首期还款金额按各期还款金额的平均增幅估算调整
+	installment := firstInstallment.Add(bestResidual.Div(decimal.NewFromInt(int64(request.TotalPeriodNum)))).Round(scale)
+	for i := 0; i < maxResidualIterations && !lastResidual.IsZero() && request.TotalPeriodNum > 1; i++ {
+		current := calculateGraduatedRecords(request, dateMap, installment)
+		residual := residualOf(current, installment)
+		if residual.Abs().LessThan(bestResidual.Abs()) {
+			best, bestResidual, bestInstallment = current, residual, installment
+		}
+		if residual.IsZero() || residual.Equal(lastResidual) {
+			break
+		}
+		nextInstallment := installment.Sub(residual.Mul(installment.Sub(lastInstallment)).Div(residual.Sub(lastResidual))).Round(scale)
+		if nextInstallment.Equal(installment) {
+			break
+		}
+		lastInstallment, lastResidual, installment = installment, residual, nextInstallment
+	}
+	// 递减时后面期次的还款金额不能小于等于0
+	for i := 0; i < lastIndex; i++ {
+		if getGraduatedInstallment(request, bestInstallment, i).LessThanOrEqual(decimal.Zero) {
+			return nil, newValidationError("stepAmount", CodeConflict, "step Amount error: installment must be greater than 0")
+		}
+	}
+	return best, nil
+}
+
+// 按首期还款金额生成各期还款计划,最后一期归还剩余本金和利息
+func calculateGraduatedRecords(request repayPlanRequest, dateMap map[int][]time.Time, firstInstallment decimal.Decimal) []RepayPlanRecord {
+	maintainPrinciple := request.LoanAmount
+	records := make([]RepayPlanRecord, 0, request.TotalPeriodNum)
+	for i := 0; i < request.TotalPeriodNum; i++ {
+		periodStartDate := dateMap[i][0]
+		periodEndDate := dateMap[i][1]
+		periodRepayDate := dateMap[i][2]
+
+		// 当前期次的利息=当前剩余本金*计息天数*日利息,计息天数和日利息按计息基准计算
+		interest, daysOfPeriod := calculatePeriodInterest(request, maintainPrinciple, periodStartDate, periodEndDate.AddDate(0, 0, 1))
+		periodRepayInterest := roundAmount(interest, request.RoundingPolicy)
+
+		record := RepayPlanRecord{
+			PeriodNum:           i + 1,
+			PeriodStartDate:     periodStartDate.Format(DATE_DASH_FORMAT),
+			PeriodEndDate:       periodEndDate.Format(DATE_DASH_FORMAT),
+			PeriodRepayDate:     periodRepayDate.Format(DATE_DASH_FORMAT),
+			DaysOfPeriod:        int(daysOfPeriod),
+			PeriodRepayInterest: periodRepayInterest,
+			DayCountConvention:  request.DayCountConvention,
+			InterestRate:        getInterestRateOf(request, periodEndDate),
+		}
+		if i == request.TotalPeriodNum-1 {
+			record.PeriodRepayPrinciple = maintainPrinciple
+			record.PeriodRepayTotalAmount = periodRepayInterest.Add(maintainPrinciple)
+		} else {
+			installment := getGraduatedInstallment(request, firstInstallment, i)
+			if installment.LessThan(periodRepayInterest) {
+				// 负摊还:还款金额不足利息,未还的利息计入本金
+				record.PeriodRepayInterest = installment
+				record.CapitalizedInterest = periodRepayInterest.Sub(installment)
+				maintainPrinciple = maintainPrinciple.Add(record.CapitalizedInterest)
+			} else {
+				record.PeriodRepayPrinciple = installment.Sub(periodRepayInterest)
+			}
+			record.PeriodRepayTotalAmount = installment
+		}
+		maintainPrinciple = maintainPrinciple.Sub(record.PeriodRepayPrinciple)
+		record.MaintainPrinciple = maintainPrinciple
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,161 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+/**
+  *@Description 阶梯还款 每年递增5% 最后一期还清本金
+**/
+func Test_graduatedMethod(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:    decimal.NewFromFloat(300000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(4.9),
+		PeriodNum:     60,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   GraduatedRepayment,
+		PeriodType:    PeriodTypeMonth,
+		StepRate:      decimal.NewFromInt(5),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := resp.PlanRepayRecords
+	first := decimal.RequireFromString("5143.52")
+	if !records[0].PeriodRepayTotalAmount.Equal(first) || !records[11].PeriodRepayTotalAmount.Equal(first) {
+		t.Errorf("got first year installment %s %s, want %s", records[0].PeriodRepayTotalAmount, records[11].PeriodRepayTotalAmount, first)
+	}
+	// 第二年=首期×1.05,第三年=首期×1.05^2
+	if !records[12].PeriodRepayTotalAmount.Equal(decimal.RequireFromString("5400.70")) ||
+		!records[24].PeriodRepayTotalAmount.Equal(decimal.RequireFromString("5670.73")) {
+		t.Errorf("got installment %s %s", records[12].PeriodRepayTotalAmount, records[24].PeriodRepayTotalAmount)
+	}
+	last := records[len(records)-1]
+	if !last.MaintainPrinciple.IsZero() || last.PeriodRepayTotalAmount.Sub(records[58].PeriodRepayTotalAmount).Abs().GreaterThan(decimal.NewFromInt(1)) {
+		t.Errorf("unexpected last period %+v", last)
+	}
+	if resp.StepPeriodNum != 12 || !resp.StepRate.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("got step period num %d step rate %s", resp.StepPeriodNum, resp.StepRate)
+	}
+}
+
+/**
+  *@Description 阶梯还款 前期还款金额不足利息 未还的利息计入本金
+**/
+func Test_graduatedMethodNegativeAmortization(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:    decimal.NewFromFloat(300000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     120,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   GraduatedRepayment,
+		PeriodType:    PeriodTypeMonth,
+		StepRate:      decimal.NewFromInt(20),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := resp.PlanRepayRecords
+	first := records[0]
+	if !first.PeriodRepayPrinciple.IsZero() || !first.PeriodRepayInterest.Equal(first.PeriodRepayTotalAmount) ||
+		!first.CapitalizedInterest.IsPositive() || !first.MaintainPrinciple.Equal(decimal.NewFromInt(300000).Add(first.CapitalizedInterest)) {
+		t.Errorf("unexpected first period %+v", first)
+	}
+	capitalizedInterest := decimal.Zero
+	for _, record := range records {
+		capitalizedInterest = capitalizedInterest.Add(record.CapitalizedInterest)
+	}
+	// 总还款金额=贷款金额+总利息,计入本金的利息也在总利息中
+	if !records[len(records)-1].MaintainPrinciple.IsZero() ||
+		!resp.TotalRepayAmount.Equal(resp.LoanAmount.Add(resp.TotalInterest)) || capitalizedInterest.LessThanOrEqual(decimal.Zero) {
+		t.Errorf("got total repay amount %s total interest %s", resp.TotalRepayAmount, resp.TotalInterest)
+	}
+}
+
+/**
+  *@Description 阶梯还款 每年递减固定金额
+**/
+func Test_graduatedMethodStepDown(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromFloat(300000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     36,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   GraduatedRepayment,
+		PeriodType:    PeriodTypeMonth,
+		StepAmount:    decimal.NewFromInt(-300),
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := resp.PlanRepayRecords
+	if !records[0].PeriodRepayTotalAmount.Sub(records[12].PeriodRepayTotalAmount).Equal(decimal.NewFromInt(300)) ||
+		!records[12].PeriodRepayTotalAmount.Sub(records[24].PeriodRepayTotalAmount).Equal(decimal.NewFromInt(300)) {
+		t.Errorf("got installment %s %s %s", records[0].PeriodRepayTotalAmount, records[12].PeriodRepayTotalAmount, records[24].PeriodRepayTotalAmount)
+	}
+	if !records[35].MaintainPrinciple.IsZero() {
+		t.Errorf("unexpected last period %+v", records[35])
+	}
+
+	// 递减后还款金额小于等于0
+	request.StepAmount = decimal.NewFromInt(-12000)
+	if _, err = CalculateRepaymentPlan(request); !errors.Is(err, ErrConflict) {
+		t.Errorf("got %v, want step amount conflict", err)
+	}
+}
+
+func Test_checkGraduated(t *testing.T) {
+	cases := []struct {
+		repayMethod   RepayMethod
+		stepPeriodNum int
+		stepRate      string
+		stepAmount    string
+		want          []*ValidationError
+	}{
+		// 比例和金额同时设置,调整间隔期数为负
+		{GraduatedRepayment, -1, "5", "100", []*ValidationError{{Code: CodeConflict}, {Field: "stepPeriodNum", Code: CodeInvalid}}},
+		{EqualLoanRepayment, 0, "5", "0", []*ValidationError{{Code: CodeUnsupported}}},
+	}
+	for _, c := range cases {
+		_, err := CalculateRepaymentPlan(&Request{
+			LoanAmount:    decimal.NewFromFloat(300000),
+			LoanStartDate: "2022-01-01",
+			InterestRate:  decimal.NewFromFloat(6),
+			PeriodNum:     36,
+			RepayDay:      1,
+			LoanCycleCode: LoanCycleMonthly,
+			RepayMethod:   c.repayMethod,
+			PeriodType:    PeriodTypeMonth,
+			StepPeriodNum: c.stepPeriodNum,
+			StepRate:      decimal.RequireFromString(c.stepRate),
+			StepAmount:    decimal.RequireFromString(c.stepAmount),
+		})
+		var validationErrors ValidationErrors
+		var validationError *ValidationError
+		if !errors.As(err, &validationErrors) && errors.As(err, &validationError) {
+			validationErrors = ValidationErrors{validationError}
+		}
+		if len(validationErrors) == 0 {
+			t.Errorf("%s: got %v, want validation error", c.repayMethod, err)
+			continue
+		}
+		if len(validationErrors) != len(c.want) {
+			t.Errorf("%s: got %v", c.repayMethod, err)
+			continue
+		}
+		for i, want := range c.want {
+			if validationErrors[i].Code != want.Code || want.Field != "" && validationErrors[i].Field != want.Field {
+				t.Errorf("%s: error %d got %+v, want %+v", c.repayMethod, i, validationErrors[i], want)
+			}
+		}
+	}
+}
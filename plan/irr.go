@@ -0,0 +1,159 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+const (
+	// 内部收益率二分法的最大迭代次数和精度
+	maxIRRIterations = 200
+	irrPrecision     = "0.000000000001"
+	// 折现因子保留的小数位数
+	discountPrecision = 24
+	// 年化利率保留的小数位数(%)
+	annualRateScale = 4
+)
+
+// 一笔现金流:金额和距放款日的期数(按计息基准折算的年数×每年期数)
+type cashFlow struct {
+	amount  decimal.Decimal
+	periods decimal.Decimal
+}
+
+/**
+  *@Description 按还款计划各笔现金流的实际日期计算内部收益率，并按还款周期折算年化利率(APR)和实际年利率(EAR)，放款时收取的手续费从放款金额中扣除
+**/
+func fillAnnualRate(response *Response) error {
+	if len(response.PlanRepayRecords) == 0 {
+		return nil
+	}
+	periodsOfYear := getPeriodsOfYear(response)
+	cashFlows, err := getPlanCashFlows(response, periodsOfYear)
+	if err != nil {
+		return err
+	}
+	periodIRR, err := calculateIRR(cashFlows)
+	if err != nil {
+		return err
+	}
+	effectiveAnnualRate, err := periodIRR.Add(decimal.NewFromInt(1)).PowWithPrecision(periodsOfYear, int32(decimal.DivisionPrecision))
+	if err != nil {
+		return errors.New("effective annual rate error: " + err.Error())
+	}
+	hundred := decimal.NewFromInt(100)
+	response.PeriodIRR = periodIRR.Mul(hundred).Round(annualRateScale)
+	response.AnnualPercentageRate = periodIRR.Mul(periodsOfYear).Mul(hundred).Round(annualRateScale)
+	response.EffectiveAnnualRate = effectiveAnnualRate.Sub(decimal.NewFromInt(1)).Mul(hundred).Round(annualRateScale)
+	return nil
+}
+
+// 还款计划的现金流:放款日为实际放款金额(负),之后为各期还款日的还款总金额,期数按计息基准从放款日折算,首期不足一期或提前还款时不是整数
+func getPlanCashFlows(response *Response, periodsOfYear decimal.Decimal) ([]cashFlow, error) {
+	loanStartDate, err := time.ParseInLocation(DATE_DASH_FORMAT, response.LoanStartDate, time.Local)
+	if err != nil {
+		return nil, errors.New("interest Calculate Start Date error")
+	}
+	daysOfYear := getAnnualRateDaysOfYear(response)
+	cashFlows := make([]cashFlow, 0, len(response.PlanRepayRecords)+1)
+	cashFlows = append(cashFlows, cashFlow{amount: response.LoanAmount.Sub(response.UpfrontFee).Neg()})
+	for _, record := range response.PlanRepayRecords {
+		repayDate, err := time.ParseInLocation(DATE_DASH_FORMAT, record.PeriodRepayDate, time.Local)
+		if err != nil {
+			return nil, errors.New("period Repay Date error")
+		}
+		// 本金1按100%的年利率计息,利息即按计息基准折算的年数
+		years, _ := calculateInterest(decimal.NewFromInt(1), decimal.NewFromInt(100), response.DayCountConvention, daysOfYear, loanStartDate, repayDate)
+		cashFlows = append(cashFlows, cashFlow{amount: record.PeriodRepayTotalAmount, periods: years.Mul(periodsOfYear).Round(discountPrecision)})
+	}
+	return cashFlows, nil
+}
+
+// 折算年化利率的年天数:按计息基准,未设置时按年天数
+func getAnnualRateDaysOfYear(response *Response) int {
+	if response.DaysOfYear == 0 {
+		return getDaysOfYearOfConvention(response.DayCountConvention, daysOfYear)
+	}
+	return getDaysOfYearOfConvention(response.DayCountConvention, response.DaysOfYear)
+}
+
+// 每年期数:利随本清只有一期,按计息天数折算
+func getPeriodsOfYear(response *Response) decimal.Decimal {
+	days := getAnnualRateDaysOfYear(response)
+	if response.RepayMethod == BothPrincipalAndInterest {
+		return decimal.NewFromInt(int64(days)).Div(decimal.NewFromInt(int64(response.PlanRepayRecords[0].DaysOfPeriod)))
+	}
+	switch response.LoanCycleCode {
+	case LoanCycleDaily:
+		return decimal.NewFromInt(int64(days))
+	case LoanCycleFortnightly:
+		return decimal.NewFromInt(numberOfWeek)
+	case LoanCycleQuarterly:
+		return decimal.NewFromInt(numberOfQuarter)
+	case LoanCycleYearly:
+		return decimal.NewFromInt(1)
+	}
+	return decimal.NewFromInt(numberOfMonth)
+}
+
+// 二分法求每期内部收益率:使现金流按该利率折现后的净现值为0
+func calculateIRR(cashFlows []cashFlow) (decimal.Decimal, error) {
+	low, high := decimal.RequireFromString("-0.99"), decimal.NewFromInt(1)
+	// 净现值随利率递减,扩大上界直到净现值为负
+	for i := 0; ; i++ {
+		npv, err := calculateNPV(cashFlows, high)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if !npv.IsPositive() {
+			break
+		}
+		if i == maxIRRIterations {
+			return decimal.Zero, errors.New("irr not found")
+		}
+		low, high = high, high.Mul(decimal.NewFromInt(2))
+	}
+	if npv, err := calculateNPV(cashFlows, low); err != nil || npv.IsNegative() {
+		return decimal.Zero, errors.New("irr not found")
+	}
+	precision := decimal.RequireFromString(irrPrecision)
+	two := decimal.NewFromInt(2)
+	for i := 0; i < maxIRRIterations && high.Sub(low).GreaterThan(precision); i++ {
+		mid := low.Add(high).Div(two)
+		npv, err := calculateNPV(cashFlows, mid)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if npv.IsPositive() {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return low.Add(high).Div(two), nil
+}
+
+// 净现值=∑现金流/(1+利率)^期数,期数可以不是整数
+// 现金流按日期先后排列,折现因子按相邻两笔现金流的期数间隔逐笔相乘,相同间隔的因子只计算一次
+func calculateNPV(cashFlows []cashFlow, rate decimal.Decimal) (decimal.Decimal, error) {
+	base := rate.Add(decimal.NewFromInt(1))
+	factors := make(map[string]decimal.Decimal)
+	npv, factor, previous := decimal.Zero, decimal.NewFromInt(1), decimal.Zero
+	for _, cashFlow := range cashFlows {
+		if interval := cashFlow.periods.Sub(previous); !interval.IsZero() {
+			intervalFactor, ok := factors[interval.String()]
+			if !ok {
+				var err error
+				if intervalFactor, err = base.PowWithPrecision(interval.Neg(), discountPrecision); err != nil {
+					return decimal.Zero, errors.New("irr discount factor error: " + err.Error())
+				}
+				factors[interval.String()] = intervalFactor
+			}
+			factor = factor.Mul(intervalFactor).Round(discountPrecision)
+			previous = cashFlow.periods
+		}
+		npv = npv.Add(cashFlow.amount.Mul(factor))
+	}
+	return npv, nil
+}
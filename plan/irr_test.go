@@ -0,0 +1,205 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func Test_calculateIRR(t *testing.T) {
+	// 放款1000,一年后还1100
+	irr, err := calculateIRR([]cashFlow{{amount: decimal.NewFromInt(-1000)}, {amount: decimal.NewFromInt(1100), periods: decimal.NewFromInt(1)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !irr.Round(8).Equal(decimal.RequireFromString("0.1")) {
+		t.Errorf("got irr %s, want 0.1", irr)
+	}
+	if _, err = calculateIRR([]cashFlow{{amount: decimal.NewFromInt(1000)}, {amount: decimal.NewFromInt(1100), periods: decimal.NewFromInt(1)}}); err == nil {
+		t.Error("expected error for cash flows without sign change")
+	}
+}
+
+/**
+  *@Description 内部收益率按十进制折现 报告的每期内部收益率在其精度内使净现值变号 现金流等比放大时结果不变
+**/
+func Test_calculateIRRStable(t *testing.T) {
+	cases := []struct {
+		repayMethod RepayMethod
+		convention  DayCountConvention
+		periodNum   int
+		upfrontFee  int64
+	}{
+		{EqualLoanRepayment, DayCountAct360, 360, 0},
+		{EqualPrincipalRepayment, DayCountActActISDA, 60, 1000},
+		{EqualPrincipalAndInterest, DayCountAct365F, 24, 0},
+	}
+	// 报告的每期内部收益率(%)保留4位小数,即利率精度为0.000001
+	halfUnit := decimal.New(5, -7)
+	for _, c := range cases {
+		resp, err := CalculateRepaymentPlan(&Request{
+			LoanAmount:         decimal.NewFromFloat(400000),
+			LoanStartDate:      "2022-01-15",
+			InterestRate:       decimal.NewFromFloat(4.9),
+			PeriodNum:          c.periodNum,
+			RepayDay:           20,
+			LoanCycleCode:      LoanCycleMonthly,
+			RepayMethod:        c.repayMethod,
+			PeriodType:         PeriodTypeMonth,
+			DayCountConvention: c.convention,
+			UpfrontFee:         decimal.NewFromInt(c.upfrontFee),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cashFlows, err := getPlanCashFlows(resp, getPeriodsOfYear(resp))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rate := resp.PeriodIRR.Div(decimal.NewFromInt(100))
+		low, err := calculateNPV(cashFlows, rate.Sub(halfUnit))
+		if err != nil {
+			t.Fatal(err)
+		}
+		high, err := calculateNPV(cashFlows, rate.Add(halfUnit))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if low.IsNegative() || high.IsPositive() {
+			t.Errorf("%s %s: period irr %s not within npv root, npv %s %s", c.repayMethod, c.convention, resp.PeriodIRR, low, high)
+		}
+		for i := range cashFlows {
+			cashFlows[i].amount = cashFlows[i].amount.Mul(decimal.NewFromInt(1000))
+		}
+		irr, err := calculateIRR(cashFlows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := irr.Mul(decimal.NewFromInt(100)).Round(annualRateScale); !got.Equal(resp.PeriodIRR) {
+			t.Errorf("%s %s: got period irr %s for scaled cash flows, want %s", c.repayMethod, c.convention, got, resp.PeriodIRR)
+		}
+	}
+}
+
+/**
+  *@Description 等额本息 30/360 年化利率等于名义利率 手续费提高实际年化利率
+**/
+func Test_annualRateOfFixedInstallment(t *testing.T) {
+	request := &Request{
+		LoanAmount:         decimal.NewFromFloat(120000),
+		LoanStartDate:      "2022-01-01",
+		InterestRate:       decimal.NewFromFloat(6),
+		PeriodNum:          12,
+		RepayDay:           1,
+		LoanCycleCode:      LoanCycleMonthly,
+		RepayMethod:        EqualLoanRepayment,
+		PeriodType:         PeriodTypeMonth,
+		DayCountConvention: DayCount30360,
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.AnnualPercentageRate.Round(2).Equal(decimal.NewFromInt(6)) || !resp.EffectiveAnnualRate.Round(2).Equal(decimal.RequireFromString("6.17")) {
+		t.Errorf("got apr %s ear %s, want 6.00 6.17", resp.AnnualPercentageRate, resp.EffectiveAnnualRate)
+	}
+
+	request.UpfrontFee = decimal.NewFromInt(1200)
+	feeResp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !feeResp.AnnualPercentageRate.GreaterThan(resp.AnnualPercentageRate.Add(decimal.NewFromInt(1))) {
+		t.Errorf("apr with fee %s not greater than %s", feeResp.AnnualPercentageRate, resp.AnnualPercentageRate)
+	}
+}
+
+/**
+  *@Description 等本等息 名义利率6% 实际年化利率约为名义利率的1.8倍
+**/
+func Test_annualRateOfEqualPrincipalAndInterest(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:         decimal.NewFromFloat(120000),
+		LoanStartDate:      "2022-01-01",
+		InterestRate:       decimal.NewFromFloat(6),
+		PeriodNum:          12,
+		RepayDay:           1,
+		LoanCycleCode:      LoanCycleMonthly,
+		RepayMethod:        EqualPrincipalAndInterest,
+		PeriodType:         PeriodTypeMonth,
+		DayCountConvention: DayCount30360,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.AnnualPercentageRate.Round(1).Equal(decimal.RequireFromString("10.9")) {
+		t.Errorf("got apr %s, want 10.9", resp.AnnualPercentageRate)
+	}
+}
+
+/**
+  *@Description 等额本息 部分提前还款和提前结清 按现金流的实际日期折现 无费用时年化利率不低于名义利率
+**/
+func Test_annualRateOfPrepayment(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:         decimal.NewFromFloat(120000),
+		LoanStartDate:      "2022-01-01",
+		InterestRate:       decimal.NewFromFloat(6),
+		PeriodNum:          12,
+		RepayDay:           1,
+		LoanCycleCode:      LoanCycleMonthly,
+		RepayMethod:        EqualLoanRepayment,
+		PeriodType:         PeriodTypeMonth,
+		DayCountConvention: DayCount30360,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []*PrepaymentRequest{
+		{PrepayDate: "2022-04-01", PrepayAmount: decimal.NewFromInt(30000), Strategy: PrepayShortenTerm},
+		{PrepayDate: "2022-04-15", PrepayAmount: decimal.NewFromInt(30000), Strategy: PrepayReduceInstallment},
+		{PrepayDate: "2022-04-15", PrepayAmount: resp.PlanRepayRecords[2].MaintainPrinciple, Strategy: PrepayShortenTerm},
+	}
+	for _, c := range cases {
+		prepayResp, err := CalculatePrepaymentPlan(resp, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prepayResp.AnnualPercentageRate.LessThan(resp.InterestRate) {
+			t.Errorf("prepay %s %s: apr %s less than interest rate %s", c.PrepayDate, c.PrepayAmount, prepayResp.AnnualPercentageRate, resp.InterestRate)
+		}
+	}
+}
+
+/**
+  *@Description 首期不足一期 按日还款ACT/365F 无费用时年化利率不低于名义利率
+**/
+func Test_annualRateOfStubPeriod(t *testing.T) {
+	cases := []*Request{
+		{
+			LoanStartDate:      "2022-01-10",
+			PeriodNum:          12,
+			RepayDay:           1,
+			LoanCycleCode:      LoanCycleMonthly,
+			DayCountConvention: DayCount30360,
+		},
+		{
+			LoanStartDate:      "2022-01-01",
+			PeriodNum:          30,
+			LoanCycleCode:      LoanCycleDaily,
+			DayCountConvention: DayCountAct365F,
+		},
+	}
+	for _, c := range cases {
+		c.LoanAmount = decimal.NewFromInt(100000)
+		c.InterestRate = decimal.NewFromInt(10)
+		c.RepayMethod = EqualLoanRepayment
+		c.PeriodType = PeriodTypeMonth
+		resp, err := CalculateRepaymentPlan(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.AnnualPercentageRate.LessThan(resp.InterestRate) {
+			t.Errorf("%s: apr %s less than interest rate %s", c.LoanCycleCode, resp.AnnualPercentageRate, resp.InterestRate)
+		}
+	}
+}
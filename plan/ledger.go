@@ -0,0 +1,418 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"sort"
+	"time"
+)
+
+// WaterfallBucket 实际还款冲抵的科目
+type WaterfallBucket string
+
+// 冲抵科目 逾期为还款日早于还款日期的期次,当期为还款日不早于还款日期的第一期
+const (
+	BucketFee              WaterfallBucket = "fee"              // 费用
+	BucketPenalty          WaterfallBucket = "penalty"          // 罚息和复利
+	BucketOverdueInterest  WaterfallBucket = "overdueInterest"  // 逾期利息
+	BucketOverduePrinciple WaterfallBucket = "overduePrinciple" // 逾期本金
+	BucketCurrentInterest  WaterfallBucket = "currentInterest"  // 当期利息
+	BucketCurrentPrinciple WaterfallBucket = "currentPrinciple" // 当期本金
+)
+
+// DefaultWaterfall 默认冲抵顺序:费用、罚息复利、逾期利息、逾期本金、当期利息、当期本金
+// 冲抵按科目进行 一个科目冲抵完所有适用期次(按期次先后)后再冲抵下一个科目,费用和罚息复利适用所有期次
+// 例如两期逾期时先冲抵两期的利息 再冲抵第一期的本金
+var DefaultWaterfall = []WaterfallBucket{
+	BucketFee, BucketPenalty, BucketOverdueInterest, BucketOverduePrinciple, BucketCurrentInterest, BucketCurrentPrinciple,
+}
+
+// PeriodStatus 期次的还款状态
+type PeriodStatus string
+
+// 期次的还款状态
+const (
+	PeriodPending PeriodStatus = "pending" // 未到期
+	PeriodPartial PeriodStatus = "partial" // 未到期,已部分还款
+	PeriodOverdue PeriodStatus = "overdue" // 已到期未结清
+	PeriodPaid    PeriodStatus = "paid"    // 已结清
+)
+
+// 默认罚息利率为合同利率的1.5倍
+var defaultPenaltyRateMultiple = decimal.NewFromFloat(1.5)
+
+// Payment 借款人的一笔实际还款
+type Payment struct {
+	PaymentDate string          `json:"paymentDate" validate:"required"` // 还款日期
+	Amount      decimal.Decimal `json:"amount" validate:"required"`      // 还款金额
+}
+
+// Fee 应收的一笔费用
+type Fee struct {
+	FeeDate string          `json:"feeDate" validate:"required"` // 应收日期
+	Amount  decimal.Decimal `json:"amount" validate:"required"`  // 金额
+}
+
+// LedgerRequest 还款入账请求参数
+type LedgerRequest struct {
+	AsOfDate             string            `json:"asOfDate" validate:"required"` // 计算截止日期
+	Payments             []Payment         `json:"payments"`                     // 实际还款 截止日期之后的不入账
+	Fees                 []Fee             `json:"fees"`                         // 应收费用 截止日期之后的不计入
+	Waterfall            []WaterfallBucket `json:"waterfall"`                    // 冲抵顺序 默认DefaultWaterfall,按科目跨期次冲抵,未列出的科目不冲抵
+	PenaltyRateMultiple  decimal.Decimal   `json:"penaltyRateMultiple"`          // 罚息利率为合同利率的倍数 默认1.5
+	CompoundRateMultiple decimal.Decimal   `json:"compoundRateMultiple"`         // 复利利率为合同利率的倍数 默认与罚息相同
+}
+
+// LedgerRecord 每一期的实际还款情况
+type LedgerRecord struct {
+	PeriodNum            int             `json:"periodNum"`            // 期次
+	PeriodRepayDate      string          `json:"periodRepayDate"`      // 本期还款日期
+	Status               PeriodStatus    `json:"status"`               // 还款状态
+	DaysOverdue          int             `json:"daysOverdue"`          // 逾期天数 已结清的为结清日的逾期天数
+	PaidOffDate          string          `json:"paidOffDate"`          // 结清日期 未结清为空
+	PaidPrinciple        decimal.Decimal `json:"paidPrinciple"`        // 已还本金
+	PaidInterest         decimal.Decimal `json:"paidInterest"`         // 已还利息
+	PaidPenalty          decimal.Decimal `json:"paidPenalty"`          // 已还罚息和复利
+	PenaltyInterest      decimal.Decimal `json:"penaltyInterest"`      // 逾期本金的罚息 累计计提
+	CompoundInterest     decimal.Decimal `json:"compoundInterest"`     // 逾期利息的复利 累计计提
+	OutstandingPrinciple decimal.Decimal `json:"outstandingPrinciple"` // 未还本金
+	OutstandingInterest  decimal.Decimal `json:"outstandingInterest"`  // 未还利息
+	OutstandingPenalty   decimal.Decimal `json:"outstandingPenalty"`   // 未还罚息和复利
+	MaintainPrinciple    decimal.Decimal `json:"maintainPrinciple"`    // 本期及以后各期的未还本金
+}
+
+// PaymentAllocation 一笔还款的冲抵结果
+type PaymentAllocation struct {
+	PaymentDate string          `json:"paymentDate"` // 还款日期
+	Amount      decimal.Decimal `json:"amount"`      // 还款金额
+	Fee         decimal.Decimal `json:"fee"`         // 冲抵费用
+	Penalty     decimal.Decimal `json:"penalty"`     // 冲抵罚息和复利
+	Interest    decimal.Decimal `json:"interest"`    // 冲抵利息
+	Principle   decimal.Decimal `json:"principle"`   // 冲抵本金
+	Unapplied   decimal.Decimal `json:"unapplied"`   // 未冲抵
+}
+
+// LedgerResponse 还款入账结果
+type LedgerResponse struct {
+	AsOfDate          string              `json:"asOfDate"`          // 计算截止日期
+	MaintainPrinciple decimal.Decimal     `json:"maintainPrinciple"` // 按实际还款的剩余本金
+	OutstandingFee    decimal.Decimal     `json:"outstandingFee"`    // 未还费用
+	UnappliedAmount   decimal.Decimal     `json:"unappliedAmount"`   // 未冲抵的还款合计
+	Allocations       []PaymentAllocation `json:"allocations"`       // 每笔还款的冲抵结果
+	LedgerRecords     []LedgerRecord      `json:"ledgerRecords"`     // 每一期的还款情况
+}
+
+// 入账中每一期的状态
+type ledgerPeriod struct {
+	record          *LedgerRecord
+	repayDate       time.Time
+	accrualDate     time.Time // 罚息和复利已计提到的日期
+	penaltyDayRate  decimal.Decimal
+	compoundDayRate decimal.Decimal
+}
+
+// 入账中每一笔费用的状态
+type ledgerFee struct {
+	feeDate     time.Time
+	outstanding decimal.Decimal
+}
+
+// 还款计划的入账,逾期计算和还款入账共用
+type ledger struct {
+	periods []*ledgerPeriod
+	fees    []*ledgerFee
+	policy  RoundingPolicy
+}
+
+/**
+  *@Description 还款入账：实际还款按冲抵顺序依次冲抵费用、罚息复利、逾期利息、逾期本金、当期利息、当期本金，返回每期的已还和未还金额及按实际还款的剩余本金
+**/
+func CalculateLedger(response *Response, request *LedgerRequest) (*LedgerResponse, error) {
+	asOfDate, err := checkLedger(response, request)
+	if err != nil {
+		return nil, err
+	}
+	l, err := newLedger(response, request)
+	if err != nil {
+		return nil, err
+	}
+	waterfall := request.Waterfall
+	if len(waterfall) == 0 {
+		waterfall = DefaultWaterfall
+	}
+
+	ledgerResponse := &LedgerResponse{AsOfDate: request.AsOfDate}
+	for _, payment := range getSortedPayments(request.Payments, asOfDate) {
+		paymentDate, _ := time.ParseInLocation(DATE_DASH_FORMAT, payment.PaymentDate, time.Local)
+		l.accrue(paymentDate)
+		allocation := l.apply(payment, paymentDate, waterfall)
+		ledgerResponse.UnappliedAmount = ledgerResponse.UnappliedAmount.Add(allocation.Unapplied)
+		ledgerResponse.Allocations = append(ledgerResponse.Allocations, allocation)
+	}
+	l.accrue(asOfDate)
+
+	for _, fee := range l.fees {
+		if !fee.feeDate.After(asOfDate) {
+			ledgerResponse.OutstandingFee = ledgerResponse.OutstandingFee.Add(fee.outstanding)
+		}
+	}
+	// 本期及以后各期的未还本金,从最后一期往前累加
+	maintainPrinciple := decimal.Zero
+	for i := len(l.periods) - 1; i >= 0; i-- {
+		period := l.periods[i]
+		maintainPrinciple = maintainPrinciple.Add(period.record.OutstandingPrinciple)
+		period.record.MaintainPrinciple = maintainPrinciple
+		period.fillStatus(asOfDate)
+	}
+	ledgerResponse.MaintainPrinciple = maintainPrinciple
+	ledgerResponse.LedgerRecords = make([]LedgerRecord, 0, len(l.periods))
+	for _, period := range l.periods {
+		ledgerResponse.LedgerRecords = append(ledgerResponse.LedgerRecords, *period.record)
+	}
+	return ledgerResponse, nil
+}
+
+// 检查参数,返回截止日期
+func checkLedger(response *Response, request *LedgerRequest) (time.Time, error) {
+	if response == nil || len(response.PlanRepayRecords) == 0 {
+		return time.Time{}, newValidationError("planRepayRecords", CodeRequired, "repay plan can not be empty")
+	}
+	var errs ValidationErrors
+	asOfDate, err := time.ParseInLocation(DATE_DASH_FORMAT, request.AsOfDate, time.Local)
+	if err != nil {
+		errs.add("asOfDate", CodeInvalid, "as Of Date error")
+	}
+	if request.PenaltyRateMultiple.IsNegative() {
+		errs.add("penaltyRateMultiple", CodeInvalid, "penalty Rate Multiple error")
+	}
+	if request.CompoundRateMultiple.IsNegative() {
+		errs.add("compoundRateMultiple", CodeInvalid, "compound Rate Multiple error")
+	}
+	for _, payment := range request.Payments {
+		if _, err := time.ParseInLocation(DATE_DASH_FORMAT, payment.PaymentDate, time.Local); err != nil {
+			errs.add("payments.paymentDate", CodeInvalid, "payment Date error: "+payment.PaymentDate)
+		}
+		if payment.Amount.LessThanOrEqual(decimal.Zero) {
+			errs.add("payments.amount", CodeInvalid, "payment Amount error")
+		}
+	}
+	for _, fee := range request.Fees {
+		if _, err := time.ParseInLocation(DATE_DASH_FORMAT, fee.FeeDate, time.Local); err != nil {
+			errs.add("fees.feeDate", CodeInvalid, "fee Date error: "+fee.FeeDate)
+		}
+		if fee.Amount.LessThanOrEqual(decimal.Zero) {
+			errs.add("fees.amount", CodeInvalid, "fee Amount error")
+		}
+	}
+	buckets := make(map[WaterfallBucket]bool, len(request.Waterfall))
+	for _, bucket := range request.Waterfall {
+		switch bucket {
+		case BucketFee, BucketPenalty, BucketOverdueInterest, BucketOverduePrinciple, BucketCurrentInterest, BucketCurrentPrinciple:
+		default:
+			errs.add("waterfall", CodeInvalid, "waterfall bucket error: "+string(bucket))
+		}
+		if buckets[bucket] {
+			errs.add("waterfall", CodeConflict, "waterfall bucket repeated: "+string(bucket))
+		}
+		buckets[bucket] = true
+	}
+	return asOfDate, errs.err()
+}
+
+// 截止日期之前的还款,按日期排序
+func getSortedPayments(payments []Payment, asOfDate time.Time) []Payment {
+	sorted := make([]Payment, 0, len(payments))
+	dates := make(map[string]time.Time, len(payments))
+	for _, payment := range payments {
+		// 还款日期已在参数检查时校验
+		paymentDate, _ := time.ParseInLocation(DATE_DASH_FORMAT, payment.PaymentDate, time.Local)
+		if !paymentDate.After(asOfDate) {
+			sorted = append(sorted, payment)
+			dates[payment.PaymentDate] = paymentDate
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return dates[sorted[i].PaymentDate].Before(dates[sorted[j].PaymentDate])
+	})
+	return sorted
+}
+
+func newLedger(response *Response, request *LedgerRequest) (*ledger, error) {
+	penaltyMultiple := request.PenaltyRateMultiple
+	if penaltyMultiple.IsZero() {
+		penaltyMultiple = defaultPenaltyRateMultiple
+	}
+	compoundMultiple := request.CompoundRateMultiple
+	if compoundMultiple.IsZero() {
+		compoundMultiple = penaltyMultiple
+	}
+	daysOfYear := getOverdueDaysOfYear(response)
+
+	l := &ledger{policy: response.RoundingPolicy}
+	for _, record := range response.PlanRepayRecords {
+		repayDate, err := time.ParseInLocation(DATE_DASH_FORMAT, record.PeriodRepayDate, time.Local)
+		if err != nil {
+			return nil, errors.New("period Repay Date error")
+		}
+		// 浮动利率按各期执行的利率计提
+		interestRate := record.InterestRate
+		if interestRate.IsZero() {
+			interestRate = response.InterestRate
+		}
+		l.periods = append(l.periods, &ledgerPeriod{
+			record: &LedgerRecord{
+				PeriodNum:            record.PeriodNum,
+				PeriodRepayDate:      record.PeriodRepayDate,
+				OutstandingPrinciple: record.PeriodRepayPrinciple,
+				OutstandingInterest:  record.PeriodRepayInterest,
+			},
+			repayDate:       repayDate,
+			accrualDate:     repayDate,
+			penaltyDayRate:  calculateDaysInterestRate(interestRate.Mul(penaltyMultiple), daysOfYear),
+			compoundDayRate: calculateDaysInterestRate(interestRate.Mul(compoundMultiple), daysOfYear),
+		})
+		// 还款计划中的各期费用在还款日应收,与请求中的应收费用一起冲抵
+		if record.PeriodRepayFee.IsPositive() {
+			l.fees = append(l.fees, &ledgerFee{feeDate: repayDate, outstanding: record.PeriodRepayFee})
+		}
+	}
+	for _, fee := range request.Fees {
+		feeDate, _ := time.ParseInLocation(DATE_DASH_FORMAT, fee.FeeDate, time.Local)
+		l.fees = append(l.fees, &ledgerFee{feeDate: feeDate, outstanding: fee.Amount})
+	}
+	sort.SliceStable(l.fees, func(i, j int) bool {
+		return l.fees[i].feeDate.Before(l.fees[j].feeDate)
+	})
+	return l, nil
+}
+
+// 罚息和复利的年天数:按计息基准,未设置时按年天数
+func getOverdueDaysOfYear(response *Response) int {
+	if response.DaysOfYear == 0 {
+		return getDaysOfYearOfConvention(response.DayCountConvention, daysOfYear)
+	}
+	return getDaysOfYearOfConvention(response.DayCountConvention, response.DaysOfYear)
+}
+
+// 已到期未还的本金和利息计提罚息和复利至指定日期
+func (l *ledger) accrue(date time.Time) {
+	for _, period := range l.periods {
+		if !period.accrualDate.Before(date) {
+			continue
+		}
+		record := period.record
+		days := decimal.NewFromInt(getDaysBetweenDate(period.accrualDate, date) - 1)
+		penalty := roundAmount(record.OutstandingPrinciple.Mul(period.penaltyDayRate).Mul(days), l.policy)
+		compound := roundAmount(record.OutstandingInterest.Mul(period.compoundDayRate).Mul(days), l.policy)
+		record.PenaltyInterest = record.PenaltyInterest.Add(penalty)
+		record.CompoundInterest = record.CompoundInterest.Add(compound)
+		record.OutstandingPenalty = record.OutstandingPenalty.Add(penalty).Add(compound)
+		period.accrualDate = date
+	}
+}
+
+// 按冲抵顺序冲抵一笔还款:按 waterfall 的顺序逐个科目冲抵,一个科目冲抵完全部适用期次后再冲抵下一个科目
+// 每个科目内按期次先后冲抵,最早的期次先冲抵;费用冲抵还款日期之前(含当日)收取的全部费用,罚息和复利冲抵全部期次,
+// 逾期利息、逾期本金冲抵还款日早于还款日期的期次,当期利息、当期本金只冲抵还款日不早于还款日期的第一期,剩余部分记为未冲抵
+func (l *ledger) apply(payment Payment, paymentDate time.Time, waterfall []WaterfallBucket) PaymentAllocation {
+	allocation := PaymentAllocation{PaymentDate: payment.PaymentDate, Amount: payment.Amount}
+	amount := payment.Amount
+	// 取 outstanding 中不超过剩余还款的部分冲抵
+	take := func(outstanding *decimal.Decimal, paid *decimal.Decimal, total *decimal.Decimal) {
+		value := decimal.Min(amount, *outstanding)
+		*outstanding = outstanding.Sub(value)
+		*paid = paid.Add(value)
+		*total = total.Add(value)
+		amount = amount.Sub(value)
+	}
+	paidBefore := make([]decimal.Decimal, len(l.periods))
+	for i, period := range l.periods {
+		paidBefore[i] = period.getPaidAmount()
+	}
+	overdue, current := l.getPeriodsOf(paymentDate)
+	for _, bucket := range waterfall {
+		switch bucket {
+		case BucketFee:
+			for _, fee := range l.fees {
+				if !fee.feeDate.After(paymentDate) {
+					var paid decimal.Decimal
+					take(&fee.outstanding, &paid, &allocation.Fee)
+				}
+			}
+		case BucketPenalty:
+			for _, period := range l.periods {
+				take(&period.record.OutstandingPenalty, &period.record.PaidPenalty, &allocation.Penalty)
+			}
+		case BucketOverdueInterest, BucketCurrentInterest:
+			periods := overdue
+			if bucket == BucketCurrentInterest {
+				periods = current
+			}
+			for _, period := range periods {
+				take(&period.record.OutstandingInterest, &period.record.PaidInterest, &allocation.Interest)
+			}
+		case BucketOverduePrinciple, BucketCurrentPrinciple:
+			periods := overdue
+			if bucket == BucketCurrentPrinciple {
+				periods = current
+			}
+			for _, period := range periods {
+				take(&period.record.OutstandingPrinciple, &period.record.PaidPrinciple, &allocation.Principle)
+			}
+		}
+	}
+	allocation.Unapplied = amount
+
+	// 本次还款后结清的期次
+	for i, period := range l.periods {
+		if period.isPaidOff() && period.getPaidAmount().GreaterThan(paidBefore[i]) {
+			period.setPaidOff(paymentDate)
+		}
+	}
+	return allocation
+}
+
+// 还款日期的逾期期次和当期
+func (l *ledger) getPeriodsOf(date time.Time) (overdue []*ledgerPeriod, current []*ledgerPeriod) {
+	for i, period := range l.periods {
+		if !period.repayDate.Before(date) {
+			return l.periods[:i], l.periods[i : i+1]
+		}
+	}
+	return l.periods, nil
+}
+
+func (p *ledgerPeriod) isPaidOff() bool {
+	return p.record.OutstandingPrinciple.IsZero() && p.record.OutstandingInterest.IsZero() && p.record.OutstandingPenalty.IsZero()
+}
+
+func (p *ledgerPeriod) getPaidAmount() decimal.Decimal {
+	return p.record.PaidPrinciple.Add(p.record.PaidInterest).Add(p.record.PaidPenalty)
+}
+
+func (p *ledgerPeriod) setPaidOff(date time.Time) {
+	p.record.PaidOffDate = date.Format(DATE_DASH_FORMAT)
+	if date.After(p.repayDate) {
+		p.record.DaysOverdue = int(getDaysBetweenDate(p.repayDate, date)) - 1
+	}
+}
+
+// 截止日期的还款状态和逾期天数
+func (p *ledgerPeriod) fillStatus(asOfDate time.Time) {
+	record := p.record
+	// 不需要还款的期次(如宽限期利息计入本金)到期即结清
+	if record.PaidOffDate == "" && p.isPaidOff() && !p.repayDate.After(asOfDate) {
+		p.setPaidOff(p.repayDate)
+	}
+	switch {
+	case record.PaidOffDate != "":
+		record.Status = PeriodPaid
+	case p.repayDate.Before(asOfDate):
+		record.Status = PeriodOverdue
+		record.DaysOverdue = int(getDaysBetweenDate(p.repayDate, asOfDate)) - 1
+	case record.PaidPrinciple.IsPositive() || record.PaidInterest.IsPositive():
+		record.Status = PeriodPartial
+	default:
+		record.Status = PeriodPending
+	}
+}
@@ -0,0 +1,120 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+/**
+  *@Description 默认冲抵顺序 费用、罚息复利、逾期利息、逾期本金依次冲抵,第一期结清
+**/
+func Test_calculateLedger(t *testing.T) {
+	response := getOverdueTestResponse(t)
+	ledger, err := CalculateLedger(response, &LedgerRequest{
+		AsOfDate: "2022-02-20",
+		Payments: []Payment{{PaymentDate: "2022-02-15", Amount: decimal.RequireFromString("10707.17")}},
+		Fees:     []Fee{{FeeDate: "2022-02-10", Amount: decimal.NewFromInt(50)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocation := ledger.Allocations[0]
+	want := []string{"50", "37.17", "620", "10000", "0"}
+	got := []decimal.Decimal{allocation.Fee, allocation.Penalty, allocation.Interest, allocation.Principle, allocation.Unapplied}
+	for i := range want {
+		if !got[i].Equal(decimal.RequireFromString(want[i])) {
+			t.Errorf("got allocation %v, want %v", got, want)
+			break
+		}
+	}
+	first, second := ledger.LedgerRecords[0], ledger.LedgerRecords[1]
+	if first.Status != PeriodPaid || first.PaidOffDate != "2022-02-15" || first.DaysOverdue != 14 {
+		t.Errorf("unexpected first record %+v", first)
+	}
+	if second.Status != PeriodPending || !second.MaintainPrinciple.Equal(decimal.NewFromInt(110000)) {
+		t.Errorf("unexpected second record %+v", second)
+	}
+	if len(ledger.LedgerRecords) != 12 || !ledger.MaintainPrinciple.Equal(decimal.NewFromInt(110000)) || !ledger.OutstandingFee.IsZero() {
+		t.Errorf("unexpected ledger %+v", ledger)
+	}
+}
+
+/**
+  *@Description 自定义冲抵顺序 先还逾期本金,利息和罚息仍未还
+**/
+func Test_calculateLedgerWaterfall(t *testing.T) {
+	response := getOverdueTestResponse(t)
+	ledger, err := CalculateLedger(response, &LedgerRequest{
+		AsOfDate:  "2022-02-20",
+		Payments:  []Payment{{PaymentDate: "2022-02-15", Amount: decimal.NewFromInt(10000)}},
+		Waterfall: []WaterfallBucket{BucketOverduePrinciple, BucketOverdueInterest, BucketPenalty},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := ledger.LedgerRecords[0]
+	if first.Status != PeriodOverdue || first.DaysOverdue != 19 || !first.PaidPrinciple.Equal(decimal.NewFromInt(10000)) ||
+		!first.OutstandingInterest.Equal(decimal.NewFromInt(620)) || !first.PenaltyInterest.Equal(decimal.NewFromInt(35)) {
+		t.Errorf("unexpected first record %+v", first)
+	}
+	// 本金还清后不再计提罚息,利息继续计提复利 620*6%*1.5/360*19
+	if !first.CompoundInterest.Equal(decimal.RequireFromString("2.95")) {
+		t.Errorf("got compound interest %s", first.CompoundInterest)
+	}
+}
+
+/**
+  *@Description 还款日当天按时还款,不计罚息,多还的部分未冲抵
+**/
+/**
+  *@Description 两期逾期 默认冲抵顺序按科目冲抵 先冲抵两期的罚息复利和利息 再按期次先后冲抵本金
+**/
+func Test_calculateLedgerWaterfallOrder(t *testing.T) {
+	response := getOverdueTestResponse(t)
+	ledger, err := CalculateLedger(response, &LedgerRequest{
+		AsOfDate: "2022-03-10",
+		Payments: []Payment{{PaymentDate: "2022-03-10", Amount: decimal.NewFromInt(3000)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := ledger.LedgerRecords[0], ledger.LedgerRecords[1]
+	for _, record := range []LedgerRecord{first, second} {
+		if record.Status != PeriodOverdue || !record.OutstandingPenalty.IsZero() || !record.OutstandingInterest.IsZero() {
+			t.Errorf("penalty and interest of period %d not paid %+v", record.PeriodNum, record)
+		}
+	}
+	allocation := ledger.Allocations[0]
+	if !second.PaidInterest.Equal(decimal.RequireFromString("513.33")) || !second.PaidPrinciple.IsZero() ||
+		!first.PaidPrinciple.Equal(allocation.Principle) || !allocation.Principle.IsPositive() {
+		t.Errorf("unexpected allocation %+v first %+v second %+v", allocation, first, second)
+	}
+}
+
+func Test_calculateLedgerOnTime(t *testing.T) {
+	response := getOverdueTestResponse(t)
+	ledger, err := CalculateLedger(response, &LedgerRequest{
+		AsOfDate: "2022-02-01",
+		Payments: []Payment{{PaymentDate: "2022-02-01", Amount: decimal.NewFromInt(10720)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := ledger.LedgerRecords[0]
+	if first.Status != PeriodPaid || first.DaysOverdue != 0 || !first.PenaltyInterest.IsZero() || !ledger.UnappliedAmount.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("unexpected ledger %+v", ledger)
+	}
+}
+
+func Test_calculateLedgerError(t *testing.T) {
+	response := getOverdueTestResponse(t)
+	_, err := CalculateLedger(response, &LedgerRequest{
+		AsOfDate:  "2022-02-20",
+		Waterfall: []WaterfallBucket{BucketPenalty, "unknown", BucketPenalty},
+	})
+	var validationErrors ValidationErrors
+	if !errors.As(err, &validationErrors) || len(validationErrors) != 2 || !errors.Is(err, ErrConflict) {
+		t.Errorf("got %v", err)
+	}
+}
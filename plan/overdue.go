@@ -0,0 +1,111 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// OverdueRequest 逾期计算请求参数
+type OverdueRequest struct {
+	AsOfDate             string          `json:"asOfDate" validate:"required"` // 计算截止日期
+	Payments             []Payment       `json:"payments"`                     // 实际还款 截止日期之后的不计入
+	PenaltyRateMultiple  decimal.Decimal `json:"penaltyRateMultiple"`          // 罚息利率为合同利率的倍数 默认1.5
+	CompoundRateMultiple decimal.Decimal `json:"compoundRateMultiple"`         // 复利利率为合同利率的倍数 默认与罚息相同
+}
+
+// OverdueRecord 每一期的逾期情况
+type OverdueRecord struct {
+	PeriodNum          int             `json:"periodNum"`          // 期次
+	PeriodRepayDate    string          `json:"periodRepayDate"`    // 本期还款日期
+	DaysOverdue        int             `json:"daysOverdue"`        // 逾期天数 已结清的为结清日的逾期天数
+	PaidOffDate        string          `json:"paidOffDate"`        // 结清日期 未结清为空
+	RepaidPrinciple    decimal.Decimal `json:"repaidPrinciple"`    // 已还本金
+	RepaidInterest     decimal.Decimal `json:"repaidInterest"`     // 已还利息
+	RepaidPenalty      decimal.Decimal `json:"repaidPenalty"`      // 已还罚息和复利
+	OverduePrinciple   decimal.Decimal `json:"overduePrinciple"`   // 逾期本金
+	OverdueInterest    decimal.Decimal `json:"overdueInterest"`    // 逾期利息
+	PenaltyInterest    decimal.Decimal `json:"penaltyInterest"`    // 逾期本金的罚息 累计计提
+	CompoundInterest   decimal.Decimal `json:"compoundInterest"`   // 逾期利息的复利 累计计提
+	TotalOverdueAmount decimal.Decimal `json:"totalOverdueAmount"` // 截止日期应还的逾期总金额=逾期本金+逾期利息+未还的罚息和复利
+}
+
+// OverdueResponse 逾期计算结果
+type OverdueResponse struct {
+	AsOfDate              string          `json:"asOfDate"`              // 计算截止日期
+	PenaltyInterestRate   decimal.Decimal `json:"penaltyInterestRate"`   // 罚息年利率(%)=年利率×罚息倍数 浮动利率按各期利率计提
+	CompoundInterestRate  decimal.Decimal `json:"compoundInterestRate"`  // 复利年利率(%)=年利率×复利倍数
+	TotalOverduePrinciple decimal.Decimal `json:"totalOverduePrinciple"` // 逾期本金合计
+	TotalOverdueInterest  decimal.Decimal `json:"totalOverdueInterest"`  // 逾期利息合计
+	TotalPenaltyInterest  decimal.Decimal `json:"totalPenaltyInterest"`  // 罚息合计
+	TotalCompoundInterest decimal.Decimal `json:"totalCompoundInterest"` // 复利合计
+	TotalOverdueAmount    decimal.Decimal `json:"totalOverdueAmount"`    // 逾期总金额
+	UnappliedAmount       decimal.Decimal `json:"unappliedAmount"`       // 超出全部应还金额、未冲抵的还款
+	OverdueRecords        []OverdueRecord `json:"overdueRecords"`        // 到期的期次,截止日期之前还款的未到期期次也包括在内
+}
+
+/**
+  *@Description 逾期计算：各期还款日未还的本金按罚息利率、未还的利息按复利利率按日计提，实际还款按默认冲抵顺序入账
+**/
+func CalculateOverdue(response *Response, request *OverdueRequest) (*OverdueResponse, error) {
+	ledger, err := CalculateLedger(response, &LedgerRequest{
+		AsOfDate:             request.AsOfDate,
+		Payments:             request.Payments,
+		PenaltyRateMultiple:  request.PenaltyRateMultiple,
+		CompoundRateMultiple: request.CompoundRateMultiple,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 截止日期已在生成台账时检查
+	asOfDate, _ := time.ParseInLocation(DATE_DASH_FORMAT, request.AsOfDate, time.Local)
+	penaltyMultiple := request.PenaltyRateMultiple
+	if penaltyMultiple.IsZero() {
+		penaltyMultiple = defaultPenaltyRateMultiple
+	}
+	compoundMultiple := request.CompoundRateMultiple
+	if compoundMultiple.IsZero() {
+		compoundMultiple = penaltyMultiple
+	}
+
+	overdueResponse := &OverdueResponse{
+		AsOfDate:             request.AsOfDate,
+		PenaltyInterestRate:  response.InterestRate.Mul(penaltyMultiple),
+		CompoundInterestRate: response.InterestRate.Mul(compoundMultiple),
+		UnappliedAmount:      ledger.UnappliedAmount,
+	}
+	for _, ledgerRecord := range ledger.LedgerRecords {
+		// 截止日期未到期且未还款的期次不列出
+		if ledgerRecord.Status == PeriodPending {
+			continue
+		}
+		record := OverdueRecord{
+			PeriodNum:        ledgerRecord.PeriodNum,
+			PeriodRepayDate:  ledgerRecord.PeriodRepayDate,
+			DaysOverdue:      ledgerRecord.DaysOverdue,
+			PaidOffDate:      ledgerRecord.PaidOffDate,
+			RepaidPrinciple:  ledgerRecord.PaidPrinciple,
+			RepaidInterest:   ledgerRecord.PaidInterest,
+			RepaidPenalty:    ledgerRecord.PaidPenalty,
+			PenaltyInterest:  ledgerRecord.PenaltyInterest,
+			CompoundInterest: ledgerRecord.CompoundInterest,
+		}
+		periodRepayDate, err := time.ParseInLocation(DATE_DASH_FORMAT, ledgerRecord.PeriodRepayDate, time.Local)
+		if err != nil {
+			return nil, errors.New("period Repay Date error")
+		}
+		// 未到期的期次只是提前还了一部分,不算逾期
+		if periodRepayDate.Before(asOfDate) {
+			record.OverduePrinciple = ledgerRecord.OutstandingPrinciple
+			record.OverdueInterest = ledgerRecord.OutstandingInterest
+			record.TotalOverdueAmount = record.OverduePrinciple.Add(record.OverdueInterest).Add(ledgerRecord.OutstandingPenalty)
+		}
+		overdueResponse.TotalOverduePrinciple = overdueResponse.TotalOverduePrinciple.Add(record.OverduePrinciple)
+		overdueResponse.TotalOverdueInterest = overdueResponse.TotalOverdueInterest.Add(record.OverdueInterest)
+		overdueResponse.TotalPenaltyInterest = overdueResponse.TotalPenaltyInterest.Add(record.PenaltyInterest)
+		overdueResponse.TotalCompoundInterest = overdueResponse.TotalCompoundInterest.Add(record.CompoundInterest)
+		overdueResponse.TotalOverdueAmount = overdueResponse.TotalOverdueAmount.Add(record.TotalOverdueAmount)
+		overdueResponse.OverdueRecords = append(overdueResponse.OverdueRecords, record)
+	}
+	return overdueResponse, nil
+}
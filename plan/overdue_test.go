@@ -0,0 +1,98 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func getOverdueTestResponse(t *testing.T) *Response {
+	response, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   EqualPrincipalRepayment,
+		PeriodType:    PeriodTypeMonth,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+/**
+  *@Description 第一期未还 罚息=10000*6%*1.5/360*28 复利=620*6%*1.5/360*28
+**/
+func Test_calculateOverdueUnpaid(t *testing.T) {
+	response := getOverdueTestResponse(t)
+	overdue, err := CalculateOverdue(response, &OverdueRequest{AsOfDate: "2022-03-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overdue.OverdueRecords) != 1 {
+		t.Fatalf("got %d overdue records", len(overdue.OverdueRecords))
+	}
+	record := overdue.OverdueRecords[0]
+	if record.PeriodNum != 1 || record.DaysOverdue != 28 || record.PaidOffDate != "" {
+		t.Errorf("unexpected record %+v", record)
+	}
+	want := []string{"10000", "620", "70", "4.34", "10694.34"}
+	got := []decimal.Decimal{record.OverduePrinciple, record.OverdueInterest, record.PenaltyInterest, record.CompoundInterest, record.TotalOverdueAmount}
+	for i := range want {
+		if !got[i].Equal(decimal.RequireFromString(want[i])) {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+	if !overdue.PenaltyInterestRate.Equal(decimal.NewFromInt(9)) || !overdue.TotalOverdueAmount.Equal(record.TotalOverdueAmount) {
+		t.Errorf("unexpected totals %+v", overdue)
+	}
+}
+
+/**
+  *@Description 第一期逾期14天后结清,依次冲抵罚息复利、利息、本金;第二期逾期9天 复利=513.33*6%*1.5/360*9
+**/
+func Test_calculateOverduePaid(t *testing.T) {
+	response := getOverdueTestResponse(t)
+	overdue, err := CalculateOverdue(response, &OverdueRequest{
+		AsOfDate: "2022-03-10",
+		Payments: []Payment{
+			{PaymentDate: "2022-02-15", Amount: decimal.RequireFromString("10657.17")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overdue.OverdueRecords) != 2 {
+		t.Fatalf("got %d overdue records", len(overdue.OverdueRecords))
+	}
+	first := overdue.OverdueRecords[0]
+	if first.PaidOffDate != "2022-02-15" || first.DaysOverdue != 14 || !first.TotalOverdueAmount.IsZero() ||
+		!first.RepaidPenalty.Equal(decimal.RequireFromString("37.17")) || !first.RepaidPrinciple.Equal(decimal.NewFromInt(10000)) {
+		t.Errorf("unexpected first record %+v", first)
+	}
+	second := overdue.OverdueRecords[1]
+	if second.DaysOverdue != 9 || !second.PenaltyInterest.Equal(decimal.RequireFromString("22.5")) ||
+		!second.CompoundInterest.Equal(decimal.RequireFromString("1.15")) {
+		t.Errorf("unexpected second record %+v", second)
+	}
+	if !overdue.UnappliedAmount.IsZero() {
+		t.Errorf("got unapplied %s", overdue.UnappliedAmount)
+	}
+}
+
+func Test_calculateOverdueError(t *testing.T) {
+	response := getOverdueTestResponse(t)
+	requests := []*OverdueRequest{
+		{AsOfDate: "2022-13-01"},
+		{AsOfDate: "2022-03-01", PenaltyRateMultiple: decimal.NewFromInt(-1)},
+		{AsOfDate: "2022-03-01", Payments: []Payment{{PaymentDate: "2022-02-01", Amount: decimal.Zero}}},
+	}
+	for _, request := range requests {
+		if _, err := CalculateOverdue(response, request); err == nil {
+			t.Errorf("%+v: want error", request)
+		}
+	}
+}
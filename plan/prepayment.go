@@ -0,0 +1,254 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// PrepayStrategy 部分提前还款后剩余还款计划的调整方式
+type PrepayStrategy string
+
+// 提前还款调整方式
+const (
+	PrepayShortenTerm       PrepayStrategy = "1" // 每期还款金额不变,缩短期限
+	PrepayReduceInstallment PrepayStrategy = "2" // 期限不变,减少每期还款金额
+)
+
+// PrepaymentRequest 部分提前还款请求参数
+type PrepaymentRequest struct {
+	PrepayDate   string          `json:"prepayDate" validate:"required"`   // 提前还款日期
+	PrepayAmount decimal.Decimal `json:"prepayAmount" validate:"required"` // 提前归还的本金,不含截至提前还款日的利息
+	Strategy     PrepayStrategy  `json:"strategy" validate:"required"`     // 调整方式:1-缩短期限 2-减少每期还款金额
+}
+
+/**
+  *@Description 部分提前还款：提前还款日之前的期次不变，提前还款日归还部分本金及截至当日的利息，剩余本金按调整方式在原还款日上重新生成还款计划
+**/
+func CalculatePrepaymentPlan(response *Response, request *PrepaymentRequest) (*Response, error) {
+	prepayDate, err := checkPrepayment(response, request)
+	if err != nil {
+		return nil, err
+	}
+	records := response.PlanRepayRecords
+
+	// 提前还款日所在的期次
+	current, err := getCurrentPeriodIndex(records, prepayDate)
+	if err != nil {
+		return nil, err
+	}
+	if current == len(records) {
+		return nil, newValidationError("prepayDate", CodeConflict, "prepay Date can not after or equal than loan end date")
+	}
+
+	// 提前还款前的剩余本金
+	maintainPrinciple := getMaintainPrincipleBefore(response, current)
+	if request.PrepayAmount.GreaterThan(maintainPrinciple) {
+		return nil, newValidationError("prepayAmount", CodeConflict, "prepay Amount can not greater than maintain principle")
+	}
+
+	planRequest, err := getResponsePlanRequest(response, maintainPrinciple.Sub(request.PrepayAmount), prepayDate)
+	if err != nil {
+		return nil, err
+	}
+	periodStartDate, err := time.ParseInLocation(DATE_DASH_FORMAT, records[current].PeriodStartDate, time.Local)
+	if err != nil {
+		return nil, errors.New("period Start Date error")
+	}
+
+	newRecords := make([]RepayPlanRecord, 0, len(records)+1)
+	newRecords = append(newRecords, records[:current]...)
+	if !prepayDate.After(periodStartDate) {
+		// 提前还款日即本期起息日(上一期还款日),本期尚未计息,提前归还的本金并入上一期
+		prepayRecord := &newRecords[current-1]
+		prepayRecord.PeriodRepayPrinciple = prepayRecord.PeriodRepayPrinciple.Add(request.PrepayAmount)
+		prepayRecord.PeriodRepayTotalAmount = prepayRecord.PeriodRepayTotalAmount.Add(request.PrepayAmount)
+		prepayRecord.MaintainPrinciple = planRequest.LoanAmount
+		prepayRecord.IsPrepayment = true
+	} else {
+		// 截至提前还款日的利息=提前还款前的剩余本金*计息天数*日利息
+		interest, daysOfPeriod := calculatePeriodInterest(planRequest, maintainPrinciple, periodStartDate, prepayDate)
+		prepayInterest := roundAmount(interest, planRequest.RoundingPolicy)
+		newRecords = append(newRecords, RepayPlanRecord{
+			PeriodNum:              current + 1,
+			PeriodStartDate:        records[current].PeriodStartDate,
+			PeriodEndDate:          prepayDate.AddDate(0, 0, -1).Format(DATE_DASH_FORMAT),
+			PeriodRepayDate:        request.PrepayDate,
+			DaysOfPeriod:           int(daysOfPeriod),
+			PeriodRepayTotalAmount: prepayInterest.Add(request.PrepayAmount),
+			PeriodRepayPrinciple:   request.PrepayAmount,
+			PeriodRepayInterest:    prepayInterest,
+			MaintainPrinciple:      planRequest.LoanAmount,
+			DayCountConvention:     planRequest.DayCountConvention,
+			InterestRate:           getInterestRateOf(planRequest, prepayDate.AddDate(0, 0, -1)),
+			IsPrepayment:           true,
+		})
+	}
+	if planRequest.LoanAmount.GreaterThan(decimal.Zero) {
+		// 剩余期次沿用原还款日,第一期从提前还款日开始计息
+		dateMap := make(map[int][]time.Time)
+		for i, record := range records[current:] {
+			dates, e := parseRecordDates(record)
+			if e != nil {
+				return nil, e
+			}
+			if i == 0 {
+				dates[0] = prepayDate
+			}
+			dateMap[i] = dates
+		}
+		remainRecords, e := calculatePrepaymentRemainRecords(response, records[current:], planRequest, dateMap, request.Strategy)
+		if e != nil {
+			return nil, e
+		}
+		for i := range remainRecords {
+			remainRecords[i].PeriodNum = len(newRecords) + 1 + i
+		}
+		// 重新生成的期次按费用计划和新的期次收取费用
+		applyPeriodFees(remainRecords, response.FeeSchedules, response.LoanAmount.Sub(response.FinancedFee), planRequest.LoanAmount, planRequest.RoundingPolicy)
+		newRecords = append(newRecords, remainRecords...)
+	}
+
+	newResponse := *response
+	newResponse.TotalPeriodNum = len(newRecords)
+	newResponse.LoanEndDate = newRecords[len(newRecords)-1].PeriodRepayDate
+	fillRepayPlanRecords(&newResponse, newRecords)
+	newResponse.TotalFees = getTotalFees(&newResponse)
+	if err = fillAnnualRate(&newResponse); err != nil {
+		return nil, err
+	}
+	return &newResponse, nil
+}
+
+func checkPrepayment(response *Response, request *PrepaymentRequest) (time.Time, error) {
+	if response == nil || len(response.PlanRepayRecords) == 0 {
+		return time.Time{}, newValidationError("planRepayRecords", CodeRequired, "repay plan can not be empty")
+	}
+	if request.PrepayAmount.LessThanOrEqual(decimal.Zero) {
+		return time.Time{}, newValidationError("prepayAmount", CodeInvalid, "prepay Amount error")
+	}
+	prepayDate, err := time.ParseInLocation(DATE_DASH_FORMAT, request.PrepayDate, time.Local)
+	if err != nil {
+		return time.Time{}, newValidationError("prepayDate", CodeInvalid, "prepay Date error")
+	}
+	loanStartDate, err := time.ParseInLocation(DATE_DASH_FORMAT, response.LoanStartDate, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("interest Calculate Start Date error")
+	}
+	if !prepayDate.After(loanStartDate) {
+		return time.Time{}, newValidationError("prepayDate", CodeConflict, "prepay Date can not before or equal than loan start date")
+	}
+	switch response.RepayMethod {
+	case EqualLoanRepayment, EqualPrincipalRepayment:
+	case BeforeInterestAfterPrincipal:
+		if request.Strategy == PrepayShortenTerm {
+			return time.Time{}, newValidationError("strategy", CodeUnsupported, "prepay strategy error: before interest after principal can not shorten term")
+		}
+	default:
+		return time.Time{}, newValidationError("repayMethod", CodeUnsupported, "repay method error: prepayment not supported")
+	}
+	switch request.Strategy {
+	case PrepayShortenTerm, PrepayReduceInstallment:
+	default:
+		return time.Time{}, newValidationError("strategy", CodeInvalid, "prepay strategy error")
+	}
+	if response.RepayMethod == EqualLoanRepayment {
+		if e := checkLoanCycleCode(response.LoanCycleCode); nil != e {
+			return time.Time{}, e
+		}
+	}
+	return prepayDate, nil
+}
+
+// 按调整方式生成剩余本金的还款计划,remainRecords 为原计划中未到期的期次
+func calculatePrepaymentRemainRecords(response *Response, remainRecords []RepayPlanRecord, request repayPlanRequest,
+	dateMap map[int][]time.Time, strategy PrepayStrategy) ([]RepayPlanRecord, error) {
+	request.TotalPeriodNum = len(remainRecords)
+	switch response.RepayMethod {
+	case EqualLoanRepayment:
+		if strategy == PrepayReduceInstallment {
+			everyPeriodRepayAmount, err := calculateFixedInstallmentMethod(request.LoanAmount, request.PeriodInterestRate, request.TotalPeriodNum, request.RoundingPolicy)
+			if err != nil {
+				return nil, err
+			}
+			return calculateFixedInstallmentPlanRecords(request, dateMap, everyPeriodRepayAmount)
+		}
+		// 沿用原每期还款金额(取未到期的非最后一期,不含费用),剩余本金还清的期次为最后一期
+		record := remainRecords[0]
+		if len(remainRecords) > 1 {
+			record = remainRecords[len(remainRecords)-2]
+		}
+		everyPeriodRepayAmount := record.PeriodRepayTotalAmount.Sub(record.PeriodRepayFee)
+		records, err := calculateFixedInstallmentRecords(request, dateMap, everyPeriodRepayAmount, make([]decimal.Decimal, request.TotalPeriodNum))
+		if err != nil {
+			return nil, err
+		}
+		for i, record := range records {
+			if record.MaintainPrinciple.LessThanOrEqual(decimal.Zero) {
+				request.TotalPeriodNum = i + 1
+				break
+			}
+		}
+		return calculateFixedInstallmentRecords(request, dateMap, everyPeriodRepayAmount, make([]decimal.Decimal, request.TotalPeriodNum))
+	case EqualPrincipalRepayment:
+		if strategy == PrepayReduceInstallment {
+			return calculatePrincipalScheduleRecords(request, dateMap, calculateFixedPrincipalMethod(request.LoanAmount, request.TotalPeriodNum, request.RoundingPolicy)), nil
+		}
+		// 沿用原每期还款本金,剩余本金不足一期的部分在最后一期归还
+		everyPeriodRepayPrinciple := remainRecords[0].PeriodRepayPrinciple
+		if everyPeriodRepayPrinciple.LessThanOrEqual(decimal.Zero) {
+			return nil, errors.New("period Repay Principle error")
+		}
+		periodNum := int(request.LoanAmount.Div(everyPeriodRepayPrinciple).Ceil().IntPart())
+		if periodNum < request.TotalPeriodNum {
+			request.TotalPeriodNum = periodNum
+		}
+		principles := make([]decimal.Decimal, request.TotalPeriodNum)
+		for i := range principles {
+			principles[i] = everyPeriodRepayPrinciple
+		}
+		principles[request.TotalPeriodNum-1] = request.LoanAmount.Sub(everyPeriodRepayPrinciple.Mul(decimal.NewFromInt(int64(request.TotalPeriodNum - 1))))
+		return calculatePrincipalScheduleRecords(request, dateMap, principles), nil
+	case BeforeInterestAfterPrincipal:
+		// 每期只还利息,最后一期归还剩余本金
+		principles := make([]decimal.Decimal, request.TotalPeriodNum)
+		principles[request.TotalPeriodNum-1] = request.LoanAmount
+		return calculatePrincipalScheduleRecords(request, dateMap, principles), nil
+	}
+	return nil, errors.New("repay method error: prepayment not supported")
+}
+
+// 解析还款计划中某一期的开始计息日、结束计息日和还款日
+func parseRecordDates(record RepayPlanRecord) ([]time.Time, error) {
+	dates := make([]time.Time, 0, 3)
+	for _, date := range []string{record.PeriodStartDate, record.PeriodEndDate, record.PeriodRepayDate} {
+		dateParseLocal, err := time.ParseInLocation(DATE_DASH_FORMAT, date, time.Local)
+		if err != nil {
+			return nil, errors.New("repay plan date format error: " + err.Error())
+		}
+		dates = append(dates, dateParseLocal)
+	}
+	return dates, nil
+}
+
+// 日期所在期次的下标:第一个还款日晚于该日期的期次,还款日都不晚于该日期时返回期数
+func getCurrentPeriodIndex(records []RepayPlanRecord, date time.Time) (int, error) {
+	for i, record := range records {
+		periodRepayDate, err := time.ParseInLocation(DATE_DASH_FORMAT, record.PeriodRepayDate, time.Local)
+		if err != nil {
+			return 0, errors.New("period Repay Date error")
+		}
+		if periodRepayDate.After(date) {
+			return i, nil
+		}
+	}
+	return len(records), nil
+}
+
+// 第 index 期(下标)开始前的剩余本金
+func getMaintainPrincipleBefore(response *Response, index int) decimal.Decimal {
+	if index == 0 {
+		return response.LoanAmount
+	}
+	return response.PlanRepayRecords[index-1].MaintainPrinciple
+}
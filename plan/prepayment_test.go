@@ -0,0 +1,124 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func getPrepaymentTestPlan(t *testing.T, repayMethod RepayMethod) *Response {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   repayMethod,
+		PeriodType:    PeriodTypeMonth,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+/**
+  *@Description 等额本息 部分提前还款 月供不变缩短期限
+**/
+func Test_prepaymentShortenTerm(t *testing.T) {
+	resp := getPrepaymentTestPlan(t, EqualLoanRepayment)
+	prepayResp, err := CalculatePrepaymentPlan(resp, &PrepaymentRequest{
+		PrepayDate:   "2022-04-15",
+		PrepayAmount: decimal.NewFromInt(30000),
+		Strategy:     PrepayShortenTerm,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := prepayResp.PlanRepayRecords
+	prepayRecord := records[3]
+	if !prepayRecord.IsPrepayment || prepayRecord.DaysOfPeriod != 14 || !prepayRecord.PeriodRepayInterest.Equal(decimal.RequireFromString("211.56")) {
+		t.Errorf("unexpected prepayment record %+v", prepayRecord)
+	}
+	if records[4].PeriodStartDate != "2022-04-15" || !records[4].PeriodRepayTotalAmount.Equal(resp.PlanRepayRecords[0].PeriodRepayTotalAmount) {
+		t.Errorf("unexpected period after prepayment %+v", records[4])
+	}
+	if prepayResp.TotalPeriodNum != 10 || prepayResp.LoanEndDate != "2022-10-01" || !records[9].MaintainPrinciple.IsZero() {
+		t.Errorf("got %d periods ending %s, want 10 periods ending 2022-10-01", prepayResp.TotalPeriodNum, prepayResp.LoanEndDate)
+	}
+	if prepayResp.TotalInterest.GreaterThanOrEqual(resp.TotalInterest) {
+		t.Errorf("total interest %s not less than original %s", prepayResp.TotalInterest, resp.TotalInterest)
+	}
+}
+
+/**
+  *@Description 等额本金 部分提前还款 期限不变减少月供
+**/
+func Test_prepaymentReduceInstallment(t *testing.T) {
+	resp := getPrepaymentTestPlan(t, EqualPrincipalRepayment)
+	prepayResp, err := CalculatePrepaymentPlan(resp, &PrepaymentRequest{
+		PrepayDate:   "2022-04-15",
+		PrepayAmount: decimal.NewFromInt(30000),
+		Strategy:     PrepayReduceInstallment,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prepayResp.TotalPeriodNum != 13 || prepayResp.LoanEndDate != resp.LoanEndDate {
+		t.Errorf("got %d periods ending %s, want 13 periods ending %s", prepayResp.TotalPeriodNum, prepayResp.LoanEndDate, resp.LoanEndDate)
+	}
+	principal := decimal.Zero
+	for _, item := range prepayResp.PlanRepayRecords {
+		principal = principal.Add(item.PeriodRepayPrinciple)
+	}
+	if !principal.Equal(resp.LoanAmount) {
+		t.Errorf("total principal %s, want %s", principal, resp.LoanAmount)
+	}
+	if !prepayResp.PlanRepayRecords[5].PeriodRepayPrinciple.Equal(decimal.RequireFromString("6666.67")) {
+		t.Errorf("period 6 principal %s, want 6666.67", prepayResp.PlanRepayRecords[5].PeriodRepayPrinciple)
+	}
+}
+
+/**
+  *@Description 等额本息 提前还款日为还款日 提前归还的本金并入当日到期的期次 不生成零天的期次
+**/
+func Test_prepaymentOnRepayDate(t *testing.T) {
+	resp := getPrepaymentTestPlan(t, EqualLoanRepayment)
+	prepayResp, err := CalculatePrepaymentPlan(resp, &PrepaymentRequest{
+		PrepayDate:   "2022-04-01",
+		PrepayAmount: decimal.NewFromInt(30000),
+		Strategy:     PrepayReduceInstallment,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := prepayResp.PlanRepayRecords
+	original := resp.PlanRepayRecords[2]
+	if prepayResp.TotalPeriodNum != 12 || !records[2].IsPrepayment || records[2].PeriodRepayDate != "2022-04-01" ||
+		!records[2].PeriodRepayTotalAmount.Equal(original.PeriodRepayTotalAmount.Add(decimal.NewFromInt(30000))) {
+		t.Errorf("unexpected prepayment record %+v", records[2])
+	}
+	for _, item := range records {
+		if item.DaysOfPeriod <= 0 {
+			t.Errorf("period %d has %d days", item.PeriodNum, item.DaysOfPeriod)
+		}
+	}
+	if records[3].PeriodNum != 4 || records[3].PeriodStartDate != "2022-04-01" || !records[11].MaintainPrinciple.IsZero() {
+		t.Errorf("unexpected period after prepayment %+v", records[3])
+	}
+}
+
+func Test_prepaymentError(t *testing.T) {
+	resp := getPrepaymentTestPlan(t, BeforeInterestAfterPrincipal)
+	cases := []*PrepaymentRequest{
+		{PrepayDate: "2022-04-15", PrepayAmount: decimal.NewFromInt(30000), Strategy: PrepayShortenTerm},
+		{PrepayDate: "2023-01-01", PrepayAmount: decimal.NewFromInt(30000), Strategy: PrepayReduceInstallment},
+		{PrepayDate: "2022-04-15", PrepayAmount: decimal.NewFromInt(130000), Strategy: PrepayReduceInstallment},
+		{PrepayDate: "2022-04-15", PrepayAmount: decimal.Zero, Strategy: PrepayReduceInstallment},
+	}
+	for _, c := range cases {
+		if _, err := CalculatePrepaymentPlan(resp, c); err == nil {
+			t.Errorf("expected error for %+v", c)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package plan
+
+import (
+	"errors"
+	"sync"
+)
+
+// RepaymentMethod 还款方式 按还款方式代码注册后即可通过 CalculateRepaymentPlan 生成还款计划
+type RepaymentMethod interface {
+	Code() RepayMethod                        // 还款方式代码 即 Request.RepayMethod
+	Name() string                             // 还款方式名称
+	Check(request *Request) error             // 还款方式特有的参数检查 通用参数已检查并填充默认值
+	Plan(request *Request) (*Response, error) // 生成还款计划 可通过 PrepareSchedule 取得各期日期、利率和舍入规则,费用和实际年化利率由调用方填充
+}
+
+// 已注册的还款方式 codes 为注册顺序
+var repaymentMethods = struct {
+	sync.RWMutex
+	methods map[RepayMethod]RepaymentMethod
+	codes   []RepayMethod
+}{methods: make(map[RepayMethod]RepaymentMethod)}
+
+func init() {
+	for _, method := range []RepaymentMethod{
+		scheduleMethod{code: EqualLoanRepayment, name: "等额本息", plan: fixedInstallmentMethodPlan},
+		scheduleMethod{code: EqualPrincipalRepayment, name: "等额本金", plan: fixedPrincipalMethodPlan},
+		bulletMethod{},
+		scheduleMethod{code: BeforeInterestAfterPrincipal, name: "先息后本", plan: beforeInterestAfterPrincipalPlan},
+		scheduleMethod{code: EqualPrincipalAndInterest, name: "等本等息", plan: equalPrincipalAndInterestPlan},
+		scheduleMethod{code: BalloonRepayment, name: "气球贷", plan: balloonMethodPlan},
+		scheduleMethod{code: GraduatedRepayment, name: "阶梯还款", plan: graduatedMethodPlan},
+		scheduleMethod{code: RuleOf78Repayment, name: "78法则", plan: ruleOf78MethodPlan},
+		scheduleMethod{code: CustomPrincipalRepayment, name: "自定义本金", plan: customPrincipalMethodPlan},
+	} {
+		if err := RegisterRepaymentMethod(method); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterRepaymentMethod 注册还款方式 代码不能为空,不能与已注册的还款方式重复
+func RegisterRepaymentMethod(method RepaymentMethod) error {
+	if method == nil || method.Code() == "" {
+		return errors.New("repay method code can not be empty")
+	}
+	repaymentMethods.Lock()
+	defer repaymentMethods.Unlock()
+	if _, ok := repaymentMethods.methods[method.Code()]; ok {
+		return errors.New("repay method already registered: " + string(method.Code()))
+	}
+	repaymentMethods.methods[method.Code()] = method
+	repaymentMethods.codes = append(repaymentMethods.codes, method.Code())
+	return nil
+}
+
+// GetRepaymentMethod 按代码查找已注册的还款方式
+func GetRepaymentMethod(code RepayMethod) (RepaymentMethod, bool) {
+	repaymentMethods.RLock()
+	defer repaymentMethods.RUnlock()
+	method, ok := repaymentMethods.methods[code]
+	return method, ok
+}
+
+// RepaymentMethods 已注册的全部还款方式 按注册顺序,内置还款方式在前
+func RepaymentMethods() []RepaymentMethod {
+	repaymentMethods.RLock()
+	defer repaymentMethods.RUnlock()
+	methods := make([]RepaymentMethod, 0, len(repaymentMethods.codes))
+	for _, code := range repaymentMethods.codes {
+		methods = append(methods, repaymentMethods.methods[code])
+	}
+	return methods
+}
+
+// 按期数或贷款结束日期分期的还款方式:先按还款周期生成各期日期和期利率,再按还款方式生成各期
+type scheduleMethod struct {
+	code RepayMethod
+	name string
+	plan func(request repayPlanRequest, response *Response) error
+}
+
+func (m scheduleMethod) Code() RepayMethod { return m.code }
+
+func (m scheduleMethod) Name() string { return m.name }
+
+func (m scheduleMethod) Check(request *Request) error {
+	if request.PeriodNum == 0 && request.LoanEndDate == "" {
+		return newValidationError("periodNum", CodeRequired, "loanEndDate and periodNum can not be empty at the same time")
+	}
+	return nil
+}
+
+func (m scheduleMethod) Plan(request *Request) (*Response, error) {
+	schedule, err := PrepareSchedule(request)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.plan(schedule.request, schedule.response); err != nil {
+		return nil, err
+	}
+	return schedule.response, nil
+}
+
+// 利随本清:只有一期,到期日即贷款结束日期
+type bulletMethod struct{}
+
+func (bulletMethod) Code() RepayMethod { return BothPrincipalAndInterest }
+
+func (bulletMethod) Name() string { return "息随本清" }
+
+func (bulletMethod) Check(request *Request) error {
+	if request.LoanEndDate == "" {
+		return newValidationError("loanEndDate", CodeRequired, "interest Calculate End Date can not be empty")
+	}
+	return nil
+}
+
+func (bulletMethod) Plan(request *Request) (*Response, error) {
+	return bothPrincipalAndInterest(request)
+}
@@ -0,0 +1,134 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"strings"
+	"testing"
+)
+
+const testRepayMethod RepayMethod = "test-equal-principal"
+
+// 测试用的还款方式:通过 PrepareSchedule 按等额本金生成还款计划
+type testRepaymentMethod struct{}
+
+func (testRepaymentMethod) Code() RepayMethod { return testRepayMethod }
+
+func (testRepaymentMethod) Name() string { return "测试等额本金" }
+
+func (testRepaymentMethod) Check(request *Request) error {
+	if request.PeriodNum == 0 {
+		return newValidationError("periodNum", CodeRequired, "period Num can not be empty")
+	}
+	return nil
+}
+
+func (testRepaymentMethod) Plan(request *Request) (*Response, error) {
+	schedule, err := PrepareSchedule(request)
+	if err != nil {
+		return nil, err
+	}
+	return schedule.PrincipalPlan(schedule.Split(schedule.LoanAmount(), len(schedule.Periods)))
+}
+
+func init() {
+	if err := RegisterRepaymentMethod(testRepaymentMethod{}); err != nil {
+		panic(err)
+	}
+}
+
+/**
+  *@Description 注册的还款方式与内置的等额本金一样按计息基准、节假日、浮动利率和舍入规则生成还款计划，填充费用和实际年化利率
+**/
+func Test_registeredRepaymentMethod(t *testing.T) {
+	calendar, err := ParseHolidayCalendar(strings.NewReader(testHolidayFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scale := int32(0)
+	cases := []struct {
+		name    string
+		request Request
+	}{
+		{"fee", Request{FeeSchedules: []FeeSchedule{{Name: "账户管理费", ChargeType: FeeChargePeriodic, Basis: FeeBasisFixed, Amount: decimal.NewFromInt(10)}}}},
+		{"day count", Request{DayCountConvention: DayCountAct365F}},
+		{"business day", Request{Calendar: calendar, BusinessDayConvention: BusinessDayFollowing}},
+		{"rate schedule", Request{RateSchedule: []RateReset{{EffectiveDate: "2022-07-01", InterestRate: decimal.NewFromFloat(4.5)}}}},
+		{"rounding", Request{RoundingPolicy: RoundingPolicy{Mode: RoundingDown, Scale: &scale, Residual: ResidualFirst}}},
+	}
+	for _, c := range cases {
+		request := c.request
+		request.LoanAmount = decimal.NewFromFloat(100000)
+		request.LoanStartDate = "2022-01-01"
+		request.InterestRate = decimal.NewFromFloat(6)
+		request.PeriodNum = 12
+		request.RepayDay = 1
+		request.LoanCycleCode = LoanCycleMonthly
+		request.PeriodType = PeriodTypeMonth
+		request.RepayMethod = EqualPrincipalRepayment
+		base, err := CalculateRepaymentPlan(&request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.RepayMethod = testRepayMethod
+		resp, err := CalculateRepaymentPlan(&request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.RepayMethod != testRepayMethod || !resp.TotalFees.Equal(base.TotalFees) || !resp.TotalRepayAmount.Equal(base.TotalRepayAmount) ||
+			!resp.AnnualPercentageRate.Equal(base.AnnualPercentageRate) {
+			t.Errorf("%s: got method %s total fees %s total repay amount %s APR %s, want %s %s %s", c.name, resp.RepayMethod,
+				resp.TotalFees, resp.TotalRepayAmount, resp.AnnualPercentageRate, base.TotalFees, base.TotalRepayAmount, base.AnnualPercentageRate)
+		}
+		for i, record := range resp.PlanRepayRecords {
+			want := base.PlanRepayRecords[i]
+			if record.PeriodRepayDate != want.PeriodRepayDate || !record.PeriodRepayInterest.Equal(want.PeriodRepayInterest) ||
+				!record.PeriodRepayPrinciple.Equal(want.PeriodRepayPrinciple) {
+				t.Errorf("%s: period %d got %+v, want %+v", c.name, i+1, record, want)
+				break
+			}
+		}
+	}
+
+	request := &Request{
+		LoanAmount:    decimal.NewFromFloat(100000),
+		LoanStartDate: "2022-01-01",
+		LoanEndDate:   "2023-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   testRepayMethod,
+		PeriodType:    PeriodTypeMonth,
+	}
+	if _, err = CalculateRepaymentPlan(request); !errors.Is(err, ErrRequired) {
+		t.Errorf("got %v, want period Num required", err)
+	}
+	request.RepayMethod = "unknown"
+	if _, err = CalculateRepaymentPlan(request); !errors.Is(err, ErrInvalid) {
+		t.Errorf("got %v, want repay method invalid", err)
+	}
+}
+
+func Test_RegisterRepaymentMethod(t *testing.T) {
+	if err := RegisterRepaymentMethod(testRepaymentMethod{}); err == nil {
+		t.Error("registered the same method twice")
+	}
+	if err := RegisterRepaymentMethod(scheduleMethod{}); err == nil {
+		t.Error("registered a method without code")
+	}
+	// 按注册顺序列出 内置还款方式按代码顺序在前
+	methods := RepaymentMethods()
+	want := []RepayMethod{EqualLoanRepayment, EqualPrincipalRepayment, BothPrincipalAndInterest, BeforeInterestAfterPrincipal,
+		EqualPrincipalAndInterest, BalloonRepayment, GraduatedRepayment, RuleOf78Repayment, CustomPrincipalRepayment, testRepayMethod}
+	if len(methods) != len(want) {
+		t.Fatalf("got %d methods, want %d", len(methods), len(want))
+	}
+	for i, method := range methods {
+		if method.Code() != want[i] {
+			t.Errorf("method %d: got %s, want %s", i, method.Code(), want[i])
+		}
+	}
+	if method, ok := GetRepaymentMethod(BalloonRepayment); !ok || method.Name() != "气球贷" {
+		t.Errorf("got %v %v", method, ok)
+	}
+}
@@ -0,0 +1,155 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"strconv"
+)
+
+// RoundingMode 舍入方式
+type RoundingMode string
+
+// 舍入方式
+const (
+	RoundingHalfUp   RoundingMode = "half-up"   // 四舍五入
+	RoundingHalfEven RoundingMode = "half-even" // 银行家舍入(四舍六入五成双)
+	RoundingDown     RoundingMode = "down"      // 截断
+	RoundingUp       RoundingMode = "up"        // 进位
+)
+
+// ResidualPosition 尾差处理方式
+type ResidualPosition string
+
+// 尾差处理方式
+const (
+	ResidualFirst  ResidualPosition = "first"  // 尾差放在第一期
+	ResidualLast   ResidualPosition = "last"   // 尾差放在最后一期
+	ResidualSpread ResidualPosition = "spread" // 尾差按最小货币单位分摊到各期
+)
+
+// RoundingPolicy 舍入规则
+type RoundingPolicy struct {
+	Mode     RoundingMode     `json:"mode"`     // 舍入方式 half-up half-even down up 默认half-up
+	Scale    *int32           `json:"scale"`    // 保留小数位数 默认按币种
+	Currency string           `json:"currency"` // 币种 默认CNY
+	Residual ResidualPosition `json:"residual"` // 尾差处理方式 first last spread 默认last
+}
+
+const (
+	defaultCurrency = "CNY"
+	defaultScale    = 2
+	maxScale        = 10 // 保留小数位数上限
+
+	// 等额本息尾差调整的最大迭代次数
+	maxResidualIterations = 20
+)
+
+// 币种的小数位数,未列出的币种为2位
+var currencyScales = map[string]int32{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"BHD": 3,
+	"JOD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+}
+
+// 检查舍入规则并填充默认值
+func checkRoundingPolicy(policy *RoundingPolicy) error {
+	var errs ValidationErrors
+	switch policy.Mode {
+	case "":
+		policy.Mode = RoundingHalfUp
+	case RoundingHalfUp, RoundingHalfEven, RoundingDown, RoundingUp:
+	default:
+		errs.add("roundingPolicy.mode", CodeInvalid, "rounding Mode error")
+	}
+	switch policy.Residual {
+	case "":
+		policy.Residual = ResidualLast
+	case ResidualFirst, ResidualLast, ResidualSpread:
+	default:
+		errs.add("roundingPolicy.residual", CodeInvalid, "rounding Residual error")
+	}
+	if policy.Currency == "" {
+		policy.Currency = defaultCurrency
+	}
+	if policy.Scale == nil {
+		scale, ok := currencyScales[policy.Currency]
+		if !ok {
+			scale = defaultScale
+		}
+		policy.Scale = &scale
+	}
+	if *policy.Scale < 0 || *policy.Scale > maxScale {
+		errs.add("roundingPolicy.scale", CodeInvalid, "rounding Scale must be between 0 and "+strconv.Itoa(maxScale))
+	}
+	return errs.err()
+}
+
+// 小数位数,未经检查的舍入规则默认2位
+func getRoundingScale(policy RoundingPolicy) int32 {
+	if policy.Scale == nil {
+		return defaultScale
+	}
+	return *policy.Scale
+}
+
+// 按舍入规则舍入金额
+func roundAmount(amount decimal.Decimal, policy RoundingPolicy) decimal.Decimal {
+	scale := getRoundingScale(policy)
+	switch policy.Mode {
+	case RoundingHalfEven:
+		return amount.RoundBank(scale)
+	case RoundingDown:
+		return amount.RoundDown(scale)
+	case RoundingUp:
+		return amount.RoundUp(scale)
+	}
+	return amount.Round(scale)
+}
+
+// 将总金额平均拆分成 num 期,每期按舍入规则舍入,尾差按尾差处理方式分摊
+func splitAmount(total decimal.Decimal, num int, policy RoundingPolicy) []decimal.Decimal {
+	amounts := make([]decimal.Decimal, num)
+	everyAmount := roundAmount(total.Div(decimal.NewFromInt(int64(num))), policy)
+	for i := range amounts {
+		amounts[i] = everyAmount
+	}
+	allocateResidual(amounts, total.Sub(everyAmount.Mul(decimal.NewFromInt(int64(num)))), policy)
+	return amounts
+}
+
+// 将尾差 residual 按尾差处理方式加到 amounts 上
+func allocateResidual(amounts []decimal.Decimal, residual decimal.Decimal, policy RoundingPolicy) {
+	num := len(amounts)
+	if num == 0 || residual.IsZero() {
+		return
+	}
+	switch policy.Residual {
+	case ResidualFirst:
+		amounts[0] = amounts[0].Add(residual)
+	case ResidualSpread:
+		scale := getRoundingScale(policy)
+		// 每期平均分摊的部分,剩余的按最小货币单位从第一期开始逐期分摊
+		everyResidual := residual.Div(decimal.NewFromInt(int64(num))).RoundDown(scale)
+		unit := decimal.New(1, -scale)
+		if residual.IsNegative() {
+			unit = unit.Neg()
+		}
+		remain := residual.Sub(everyResidual.Mul(decimal.NewFromInt(int64(num))))
+		for i := range amounts {
+			amounts[i] = amounts[i].Add(everyResidual)
+			if !remain.IsZero() {
+				if remain.Abs().LessThan(unit.Abs()) {
+					unit = remain
+				}
+				amounts[i] = amounts[i].Add(unit)
+				remain = remain.Sub(unit)
+			}
+		}
+	default:
+		amounts[num-1] = amounts[num-1].Add(residual)
+	}
+}
@@ -0,0 +1,77 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func Test_splitAmount(t *testing.T) {
+	cases := []struct {
+		policy RoundingPolicy
+		want   []string
+	}{
+		{RoundingPolicy{Mode: RoundingHalfUp, Residual: ResidualLast}, []string{"33.33", "33.33", "33.34"}},
+		{RoundingPolicy{Mode: RoundingHalfUp, Residual: ResidualFirst}, []string{"33.34", "33.33", "33.33"}},
+		{RoundingPolicy{Mode: RoundingUp, Residual: ResidualLast}, []string{"33.34", "33.34", "33.32"}},
+		{RoundingPolicy{Mode: RoundingUp, Residual: ResidualSpread}, []string{"33.33", "33.33", "33.34"}},
+		{RoundingPolicy{Mode: RoundingDown, Residual: ResidualSpread}, []string{"33.34", "33.33", "33.33"}},
+	}
+	for _, c := range cases {
+		if err := checkRoundingPolicy(&c.policy); err != nil {
+			t.Fatal(err)
+		}
+		amounts := splitAmount(decimal.NewFromInt(100), 3, c.policy)
+		for i, amount := range amounts {
+			if !amount.Equal(decimal.RequireFromString(c.want[i])) {
+				t.Errorf("%s/%s: got %v, want %v", c.policy.Mode, c.policy.Residual, amounts, c.want)
+				break
+			}
+		}
+	}
+}
+
+func Test_checkRoundingPolicyScale(t *testing.T) {
+	policy := RoundingPolicy{Currency: "JPY"}
+	if err := checkRoundingPolicy(&policy); err != nil {
+		t.Fatal(err)
+	}
+	if *policy.Scale != 0 || policy.Mode != RoundingHalfUp || policy.Residual != ResidualLast {
+		t.Errorf("unexpected defaults %+v", policy)
+	}
+	if got := roundAmount(decimal.RequireFromString("1234.5"), policy); !got.Equal(decimal.NewFromInt(1235)) {
+		t.Errorf("got %s, want 1235", got)
+	}
+	if err := checkRoundingPolicy(&RoundingPolicy{Mode: "ceiling"}); err == nil {
+		t.Error("expected rounding mode error")
+	}
+}
+
+/**
+  *@Description 等额本息 尾差放在第一期,其余各期还款金额相同
+**/
+func Test_fixedInstallmentResidualFirst(t *testing.T) {
+	request := &Request{
+		LoanAmount:     decimal.NewFromFloat(400000),
+		LoanStartDate:  "2022-01-01",
+		InterestRate:   decimal.NewFromFloat(4.9),
+		PeriodNum:      7,
+		RepayDay:       1,
+		LoanCycleCode:  LoanCycleMonthly,
+		RepayMethod:    EqualLoanRepayment,
+		PeriodType:     PeriodTypeMonth,
+		RoundingPolicy: RoundingPolicy{Residual: ResidualFirst},
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := resp.PlanRepayRecords
+	for _, item := range records[1:] {
+		if !item.PeriodRepayTotalAmount.Equal(records[1].PeriodRepayTotalAmount) {
+			t.Errorf("period %d repay amount %s, want %s", item.PeriodNum, item.PeriodRepayTotalAmount, records[1].PeriodRepayTotalAmount)
+		}
+	}
+	if !records[len(records)-1].MaintainPrinciple.IsZero() {
+		t.Errorf("maintain principle %s after last period", records[len(records)-1].MaintainPrinciple)
+	}
+}
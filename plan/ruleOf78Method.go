@@ -0,0 +1,72 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"strconv"
+)
+
+/**
+  *@Description 78法则：与等本等息一样按贷款本金和贷款期限计算总利息，各期还款金额相等，总利息按期数倒序的数字之和分摊，前期利息多、本金少
+**/
+func ruleOf78MethodPlan(request repayPlanRequest, response *Response) error {
+	totalInterest := calculateAddOnInterest(request)
+	// 每期还款金额=(贷款本金+总利息)/期数,尾差按舍入规则分摊
+	planRepayAmounts := splitAmount(request.LoanAmount.Add(totalInterest), request.TotalPeriodNum, request.RoundingPolicy)
+	planRepayInterests := splitRuleOf78Interest(totalInterest, request.TotalPeriodNum, request.RoundingPolicy)
+	// 期限长或利率高时前期分摊的利息超过每期还款金额,本金为负,剩余本金超过贷款本金,不支持
+	for i := range planRepayInterests {
+		if planRepayInterests[i].GreaterThan(planRepayAmounts[i]) {
+			return newValidationError("periodNum", CodeConflict, "rule of 78 interest of period "+strconv.Itoa(i+1)+" "+
+				planRepayInterests[i].String()+" exceeds installment "+planRepayAmounts[i].String()+", shorten the term or lower the rate")
+		}
+	}
+
+	dateMap := request.PeriodDates
+	records := make([]RepayPlanRecord, 0, request.TotalPeriodNum)
+	maintainPrinciple := request.LoanAmount
+	for i := 0; i < request.TotalPeriodNum; i++ {
+		periodStartDate := dateMap[i][0]
+		periodEndDate := dateMap[i][1]
+		periodRepayDate := dateMap[i][2]
+
+		record := RepayPlanRecord{
+			PeriodNum:              i + 1,
+			PeriodStartDate:        periodStartDate.Format(DATE_DASH_FORMAT),
+			PeriodEndDate:          periodEndDate.Format(DATE_DASH_FORMAT),
+			PeriodRepayDate:        periodRepayDate.Format(DATE_DASH_FORMAT),
+			DaysOfPeriod:           int(getDaysOfPeriod(request.DayCountConvention, periodStartDate, periodEndDate.AddDate(0, 0, 1))),
+			PeriodRepayTotalAmount: planRepayAmounts[i],
+			PeriodRepayPrinciple:   planRepayAmounts[i].Sub(planRepayInterests[i]),
+			PeriodRepayInterest:    planRepayInterests[i],
+			DayCountConvention:     request.DayCountConvention,
+			InterestRate:           getInterestRateOf(request, periodEndDate),
+		}
+		maintainPrinciple = maintainPrinciple.Sub(record.PeriodRepayPrinciple)
+		record.MaintainPrinciple = maintainPrinciple
+		records = append(records, record)
+	}
+	fillRepayPlanRecords(response, records)
+	return nil
+}
+
+// 按78法则分摊总利息:第k期(从1开始)利息=总利息×(期数-k+1)/(1+2+...+期数),尾差按舍入规则分摊
+func splitRuleOf78Interest(totalInterest decimal.Decimal, totalPeriodNum int, policy RoundingPolicy) []decimal.Decimal {
+	sumOfDigits := decimal.NewFromInt(int64(totalPeriodNum * (totalPeriodNum + 1) / 2))
+	interests := make([]decimal.Decimal, totalPeriodNum)
+	allocated := decimal.Zero
+	for i := range interests {
+		interests[i] = roundAmount(totalInterest.Mul(decimal.NewFromInt(int64(totalPeriodNum-i))).Div(sumOfDigits), policy)
+		allocated = allocated.Add(interests[i])
+	}
+	allocateResidual(interests, totalInterest.Sub(allocated), policy)
+	return interests
+}
+
+// 78法则提前结清时退还的未到期利息:提前结清日所在期次之后各期的利息
+func getRuleOf78InterestRebate(records []RepayPlanRecord, current int) decimal.Decimal {
+	rebate := decimal.Zero
+	for _, record := range records[current+1:] {
+		rebate = rebate.Add(record.PeriodRepayInterest)
+	}
+	return rebate
+}
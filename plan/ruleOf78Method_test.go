@@ -0,0 +1,130 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+/**
+  *@Description 78法则 总利息与等本等息相同 第一期利息=总利息×12/78 最后一期利息=总利息×1/78
+**/
+func Test_ruleOf78Method(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromFloat(12000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(12),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   RuleOf78Repayment,
+		PeriodType:    PeriodTypeMonth,
+	}
+	resp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.RepayMethod = EqualPrincipalAndInterest
+	flatResp, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.TotalInterest.Equal(flatResp.TotalInterest) || !resp.TotalInterest.Equal(decimal.NewFromInt(1464)) {
+		t.Errorf("got total interest %s, want %s", resp.TotalInterest, flatResp.TotalInterest)
+	}
+	records := resp.PlanRepayRecords
+	if !records[0].PeriodRepayInterest.Equal(decimal.RequireFromString("225.23")) || !records[11].PeriodRepayInterest.Equal(decimal.RequireFromString("18.77")) {
+		t.Errorf("got interest %s %s, want 225.23 18.77", records[0].PeriodRepayInterest, records[11].PeriodRepayInterest)
+	}
+	for i, record := range records {
+		if !record.PeriodRepayTotalAmount.Sub(records[0].PeriodRepayTotalAmount).Abs().LessThanOrEqual(decimal.RequireFromString("0.01")) {
+			t.Errorf("period %d installment %s", i+1, record.PeriodRepayTotalAmount)
+		}
+		if i > 0 && !record.PeriodRepayInterest.LessThan(records[i-1].PeriodRepayInterest) {
+			t.Errorf("period %d interest %s not less than previous", i+1, record.PeriodRepayInterest)
+		}
+	}
+	if !records[11].MaintainPrinciple.IsZero() || !resp.TotalRepayAmount.Equal(decimal.NewFromInt(13464)) {
+		t.Errorf("got maintain principle %s total repay amount %s", records[11].MaintainPrinciple, resp.TotalRepayAmount)
+	}
+}
+
+/**
+  *@Description 78法则 提前结清 归还剩余各期还款金额 退还所在期次之后各期的利息
+**/
+func Test_ruleOf78Settlement(t *testing.T) {
+	resp, err := CalculateRepaymentPlan(&Request{
+		LoanAmount:    decimal.NewFromFloat(12000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(12),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   RuleOf78Repayment,
+		PeriodType:    PeriodTypeMonth,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	quote, err := CalculateSettlementQuoteOfPlan(resp, &SettlementRequest{SettlementDate: "2022-03-15"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := resp.PlanRepayRecords
+	remainAmount, rebate := decimal.Zero, decimal.Zero
+	for _, record := range records[2:] {
+		remainAmount = remainAmount.Add(record.PeriodRepayTotalAmount)
+	}
+	for _, record := range records[3:] {
+		rebate = rebate.Add(record.PeriodRepayInterest)
+	}
+	if quote.PeriodNum != 3 || !quote.AccruedInterest.Equal(records[2].PeriodRepayInterest) || !quote.InterestRebate.Equal(rebate) {
+		t.Errorf("unexpected quote %+v", quote)
+	}
+	if !quote.TotalSettlementAmount.Equal(remainAmount.Sub(rebate)) {
+		t.Errorf("got settlement amount %s, want %s", quote.TotalSettlementAmount, remainAmount.Sub(rebate))
+	}
+}
+
+/**
+  *@Description 78法则 期限长或利率高时第一期利息超过每期还款金额 本金为负 不支持
+**/
+func Test_ruleOf78MethodNegativeAmortization(t *testing.T) {
+	cases := []struct {
+		interestRate string
+		periodNum    int
+		ok           bool
+	}{
+		{"24", 36, true},
+		// 第一期利息≈120000×2/61≈3934 每期还款金额≈220000/60≈3667
+		{"24", 60, false},
+		{"12", 120, false},
+	}
+	for _, c := range cases {
+		resp, err := CalculateRepaymentPlan(&Request{
+			LoanAmount:    decimal.NewFromFloat(100000),
+			LoanStartDate: "2022-01-01",
+			InterestRate:  decimal.RequireFromString(c.interestRate),
+			PeriodNum:     c.periodNum,
+			RepayDay:      1,
+			LoanCycleCode: LoanCycleMonthly,
+			RepayMethod:   RuleOf78Repayment,
+			PeriodType:    PeriodTypeMonth,
+		})
+		if !c.ok {
+			if !errors.Is(err, ErrConflict) {
+				t.Errorf("%s%% %d periods: got %v, want period num conflict", c.interestRate, c.periodNum, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, record := range resp.PlanRepayRecords {
+			if record.PeriodRepayPrinciple.IsNegative() || record.MaintainPrinciple.GreaterThan(resp.LoanAmount) {
+				t.Errorf("%s%% %d periods: unexpected period %+v", c.interestRate, c.periodNum, record)
+				break
+			}
+		}
+	}
+}
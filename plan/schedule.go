@@ -0,0 +1,92 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"strconv"
+	"time"
+)
+
+// Schedule 按还款周期准备好的还款计划参数:各期日期已按节假日调整,利息按计息基准和利率曲线计算,金额按舍入规则舍入
+// 注册的还款方式在 Plan 中通过 PrepareSchedule 取得,与内置还款方式使用同一套参数
+type Schedule struct {
+	Periods  []SchedulePeriod // 各期的日期
+	request  repayPlanRequest
+	response *Response
+}
+
+// SchedulePeriod 一期的日期
+type SchedulePeriod struct {
+	PeriodNum int       // 期次
+	StartDate time.Time // 开始计息日
+	EndDate   time.Time // 结束计息日
+	RepayDate time.Time // 还款日 已按节假日调整
+}
+
+/**
+  *@Description 准备还款计划参数：request 为 RepaymentMethod.Plan 收到的请求，通用参数已检查并填充默认值
+**/
+func PrepareSchedule(request *Request) (*Schedule, error) {
+	planRequest, response, err := prepareGetParameter(request)
+	if err != nil {
+		return nil, err
+	}
+	periods := make([]SchedulePeriod, planRequest.TotalPeriodNum)
+	for i := range periods {
+		dates := planRequest.PeriodDates[i]
+		periods[i] = SchedulePeriod{PeriodNum: i + 1, StartDate: dates[0], EndDate: dates[1], RepayDate: dates[2]}
+	}
+	return &Schedule{Periods: periods, request: planRequest, response: response}, nil
+}
+
+// LoanAmount 贷款金额 包含计入本金的费用
+func (s *Schedule) LoanAmount() decimal.Decimal {
+	return s.request.LoanAmount
+}
+
+// PeriodInterestRate 放款日执行利率对应的期利率
+func (s *Schedule) PeriodInterestRate() decimal.Decimal {
+	return s.request.PeriodInterestRate
+}
+
+// InterestRate date 执行的年利率(%) 浮动利率按利率调整计划取值
+func (s *Schedule) InterestRate(date time.Time) decimal.Decimal {
+	return getInterestRateOf(s.request, date)
+}
+
+// Interest 本金 principal 在 period 内的利息和计息天数 按计息基准和利率曲线计算并按舍入规则舍入
+func (s *Schedule) Interest(principal decimal.Decimal, period SchedulePeriod) (decimal.Decimal, int) {
+	interest, days := calculatePeriodInterest(s.request, principal, period.StartDate, period.EndDate.AddDate(0, 0, 1))
+	return s.Round(interest), int(days)
+}
+
+// Round 按舍入规则舍入金额
+func (s *Schedule) Round(amount decimal.Decimal) decimal.Decimal {
+	return roundAmount(amount, s.request.RoundingPolicy)
+}
+
+// Split 将 total 平均分为 num 份 尾差按舍入规则的尾差处理方式分摊
+func (s *Schedule) Split(total decimal.Decimal, num int) []decimal.Decimal {
+	return splitAmount(total, num, s.request.RoundingPolicy)
+}
+
+// PrincipalPlan 按各期归还的本金生成还款计划 每期利息按剩余本金计算,各期本金之和必须等于贷款金额
+func (s *Schedule) PrincipalPlan(principles []decimal.Decimal) (*Response, error) {
+	if len(principles) != len(s.Periods) {
+		return nil, errors.New("principle num " + strconv.Itoa(len(principles)) + " must be equal to total period num " + strconv.Itoa(len(s.Periods)))
+	}
+	total := decimal.Zero
+	for _, principle := range principles {
+		total = total.Add(principle)
+	}
+	if !total.Equal(s.request.LoanAmount) {
+		return nil, errors.New("principle total " + total.String() + " must be equal to loan amount " + s.request.LoanAmount.String())
+	}
+	return s.Plan(calculatePrincipalScheduleRecords(s.request, s.request.PeriodDates, principles)), nil
+}
+
+// Plan 按给定的各期生成还款计划 累积总还款金额和总利息
+func (s *Schedule) Plan(records []RepayPlanRecord) *Response {
+	fillRepayPlanRecords(s.response, records)
+	return s.response
+}
@@ -0,0 +1,117 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// SettlementRequest 提前结清请求参数
+type SettlementRequest struct {
+	SettlementDate string          `json:"settlementDate" validate:"required"` // 提前结清日期
+	FeeRate        decimal.Decimal `json:"feeRate"`                            // 提前结清违约金费率(%),按剩余本金计算
+	FixedFee       decimal.Decimal `json:"fixedFee"`                           // 提前结清固定手续费
+}
+
+// SettlementQuote 提前结清报价
+type SettlementQuote struct {
+	SettlementDate        string             `json:"settlementDate"`        // 提前结清日期
+	PeriodNum             int                `json:"periodNum"`             // 提前结清日所在期次
+	InterestStartDate     string             `json:"interestStartDate"`     // 利息计算开始日期=上一还款日
+	DaysOfInterest        int                `json:"daysOfInterest"`        // 计息天数
+	OutstandingPrinciple  decimal.Decimal    `json:"outstandingPrinciple"`  // 剩余本金
+	AccruedInterest       decimal.Decimal    `json:"accruedInterest"`       // 截至提前结清日的应计利息
+	InterestRebate        decimal.Decimal    `json:"interestRebate"`        // 退还的未到期利息 仅78法则
+	PeriodFee             decimal.Decimal    `json:"periodFee"`             // 提前结清日所在期次未还的费用 之后各期的费用不再收取
+	SettlementFee         decimal.Decimal    `json:"settlementFee"`         // 提前结清违约金
+	TotalSettlementAmount decimal.Decimal    `json:"totalSettlementAmount"` // 提前结清总金额
+	DayCountConvention    DayCountConvention `json:"dayCountConvention"`    // 计息基准
+}
+
+// CalculateSettlementQuote 生成还款计划并计算提前结清报价
+func CalculateSettlementQuote(request *Request, settlementRequest *SettlementRequest) (*SettlementQuote, error) {
+	response, err := CalculateRepaymentPlan(request)
+	if err != nil {
+		return nil, err
+	}
+	return CalculateSettlementQuoteOfPlan(response, settlementRequest)
+}
+
+/**
+  *@Description 提前结清：提前结清日之前到期的期次视为已还，归还剩余本金、上一还款日至提前结清日的利息、所在期次的费用及违约金
+**/
+func CalculateSettlementQuoteOfPlan(response *Response, request *SettlementRequest) (*SettlementQuote, error) {
+	if response == nil || len(response.PlanRepayRecords) == 0 {
+		return nil, newValidationError("planRepayRecords", CodeRequired, "repay plan can not be empty")
+	}
+	if request.FeeRate.IsNegative() {
+		return nil, newValidationError("feeRate", CodeInvalid, "settlement Fee error")
+	}
+	if request.FixedFee.IsNegative() {
+		return nil, newValidationError("fixedFee", CodeInvalid, "settlement Fee error")
+	}
+	settlementDate, err := time.ParseInLocation(DATE_DASH_FORMAT, request.SettlementDate, time.Local)
+	if err != nil {
+		return nil, newValidationError("settlementDate", CodeInvalid, "settlement Date error")
+	}
+	loanStartDate, err := time.ParseInLocation(DATE_DASH_FORMAT, response.LoanStartDate, time.Local)
+	if err != nil {
+		return nil, errors.New("interest Calculate Start Date error")
+	}
+	if settlementDate.Before(loanStartDate) {
+		return nil, newValidationError("settlementDate", CodeConflict, "settlement Date can not before loan start date")
+	}
+
+	// 提前结清日所在的期次
+	current, err := getCurrentPeriodIndex(response.PlanRepayRecords, settlementDate)
+	if err != nil {
+		return nil, err
+	}
+	if current == len(response.PlanRepayRecords) {
+		return nil, newValidationError("settlementDate", CodeConflict, "settlement Date can not after or equal than loan end date")
+	}
+	record := response.PlanRepayRecords[current]
+	// 利息自上一期的实际还款日(已按节假日调整)起计算,第一期自放款日起计算
+	interestStartDate := loanStartDate
+	if current > 0 {
+		interestStartDate, err = time.ParseInLocation(DATE_DASH_FORMAT, response.PlanRepayRecords[current-1].PeriodRepayDate, time.Local)
+		if err != nil {
+			return nil, errors.New("period Repay Date error")
+		}
+	}
+
+	outstandingPrinciple := getMaintainPrincipleBefore(response, current)
+	planRequest, err := getResponsePlanRequest(response, outstandingPrinciple, interestStartDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// 应计利息=剩余本金*计息天数*日利息
+	interest, daysOfInterest := calculatePeriodInterest(planRequest, outstandingPrinciple, interestStartDate, settlementDate)
+	accruedInterest := roundAmount(interest, response.RoundingPolicy)
+	// 78法则的利息按还款计划分摊,应计利息为所在期次的利息,之后各期的利息全部退还
+	var interestRebate decimal.Decimal
+	if response.RepayMethod == RuleOf78Repayment {
+		accruedInterest = record.PeriodRepayInterest
+		daysOfInterest = int64(record.DaysOfPeriod)
+		interestRebate = getRuleOf78InterestRebate(response.PlanRepayRecords, current)
+	}
+
+	// 违约金=剩余本金*违约金费率+固定手续费
+	settlementFee := roundAmount(outstandingPrinciple.Mul(request.FeeRate).Div(decimal.NewFromInt(100)), response.RoundingPolicy).
+		Add(request.FixedFee)
+
+	return &SettlementQuote{
+		SettlementDate:        request.SettlementDate,
+		PeriodNum:             record.PeriodNum,
+		InterestStartDate:     interestStartDate.Format(DATE_DASH_FORMAT),
+		DaysOfInterest:        int(daysOfInterest),
+		OutstandingPrinciple:  outstandingPrinciple,
+		AccruedInterest:       accruedInterest,
+		InterestRebate:        interestRebate,
+		PeriodFee:             record.PeriodRepayFee,
+		SettlementFee:         settlementFee,
+		TotalSettlementAmount: outstandingPrinciple.Add(accruedInterest).Add(record.PeriodRepayFee).Add(settlementFee),
+		DayCountConvention:    response.DayCountConvention,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"strings"
+	"testing"
+)
+
+/**
+  *@Description 等额本金 期中提前结清
+**/
+func Test_calculateSettlementQuote(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromFloat(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(6),
+		PeriodNum:     12,
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   EqualPrincipalRepayment,
+		PeriodType:    PeriodTypeMonth,
+	}
+	quote, err := CalculateSettlementQuote(request, &SettlementRequest{
+		SettlementDate: "2022-04-15",
+		FeeRate:        decimal.NewFromInt(1),
+		FixedFee:       decimal.NewFromInt(50),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.PeriodNum != 4 || quote.InterestStartDate != "2022-04-01" || quote.DaysOfInterest != 14 {
+		t.Errorf("unexpected period %d start %s days %d", quote.PeriodNum, quote.InterestStartDate, quote.DaysOfInterest)
+	}
+	want := []decimal.Decimal{decimal.NewFromInt(90000), decimal.NewFromInt(210), decimal.NewFromInt(950), decimal.NewFromInt(91160)}
+	got := []decimal.Decimal{quote.OutstandingPrinciple, quote.AccruedInterest, quote.SettlementFee, quote.TotalSettlementAmount}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+
+	// 还款日当天结清:当期已还,无应计利息
+	quote, err = CalculateSettlementQuote(request, &SettlementRequest{SettlementDate: "2022-05-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !quote.OutstandingPrinciple.Equal(decimal.NewFromInt(80000)) || !quote.AccruedInterest.IsZero() {
+		t.Errorf("got principle %s interest %s, want 80000 and 0", quote.OutstandingPrinciple, quote.AccruedInterest)
+	}
+
+	if _, err = CalculateSettlementQuote(request, &SettlementRequest{SettlementDate: "2023-01-01"}); err == nil {
+		t.Error("expected error for settlement on loan end date")
+	}
+}
+
+/**
+  *@Description 提前结清 利息自节假日调整后的上一还款日起计算，所在期次的费用计入提前结清金额
+**/
+func Test_calculateSettlementQuoteAdjusted(t *testing.T) {
+	calendar, err := ParseHolidayCalendar(strings.NewReader(testHolidayFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name           string
+		request        Request
+		settlementDate string
+		start          string
+		days           int
+		periodFee      int64
+		total          int64
+	}{
+		// 第9期还款日 2022-10-01 顺延至 2022-10-08 剩余本金30000 利息30000*7*6%/360=35
+		{"business day", Request{Calendar: calendar, BusinessDayConvention: BusinessDayFollowing}, "2022-10-15", "2022-10-08", 7, 0, 30035},
+		// 第1期自放款日起计算
+		{"first period", Request{}, "2022-01-31", "2022-01-01", 30, 0, 120600},
+		// 所在期次的费用10 之后各期的费用不收取
+		{"period fee", Request{FeeSchedules: []FeeSchedule{{Name: "账户管理费", ChargeType: FeeChargePeriodic, Basis: FeeBasisFixed, Amount: decimal.NewFromInt(10)}}},
+			"2022-04-15", "2022-04-01", 14, 10, 90220},
+	}
+	for _, c := range cases {
+		request := c.request
+		request.LoanAmount = decimal.NewFromFloat(120000)
+		request.LoanStartDate = "2022-01-01"
+		request.InterestRate = decimal.NewFromFloat(6)
+		request.PeriodNum = 12
+		request.RepayDay = 1
+		request.LoanCycleCode = LoanCycleMonthly
+		request.RepayMethod = EqualPrincipalRepayment
+		request.PeriodType = PeriodTypeMonth
+		quote, err := CalculateSettlementQuote(&request, &SettlementRequest{SettlementDate: c.settlementDate})
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if quote.InterestStartDate != c.start || quote.DaysOfInterest != c.days || !quote.PeriodFee.Equal(decimal.NewFromInt(c.periodFee)) ||
+			!quote.TotalSettlementAmount.Equal(decimal.NewFromInt(c.total)) {
+			t.Errorf("%s: got start %s days %d fee %s total %s, want %s %d %d %d", c.name, quote.InterestStartDate, quote.DaysOfInterest,
+				quote.PeriodFee, quote.TotalSettlementAmount, c.start, c.days, c.periodFee, c.total)
+		}
+	}
+}
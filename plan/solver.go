@@ -0,0 +1,198 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+)
+
+// SolveTarget 反向求解的未知参数
+type SolveTarget string
+
+// 反向求解的未知参数
+const (
+	SolveLoanAmount   SolveTarget = "loanAmount"   // 贷款金额
+	SolveInterestRate SolveTarget = "interestRate" // 年利率
+	SolvePeriodNum    SolveTarget = "periodNum"    // 期数
+)
+
+const (
+	// 反向求解的最大迭代次数
+	maxSolveIterations = 200
+	// 求解年利率的上限(%)和精度
+	maxSolveInterestRate     = 1000
+	solveInterestRateScale   = 4
+	solveInterestRateMinStep = "0.000001"
+)
+
+// SolveResponse 反向求解结果
+type SolveResponse struct {
+	Target      SolveTarget     `json:"target"`      // 求解的参数
+	Value       decimal.Decimal `json:"value"`       // 求解结果:贷款金额、年利率(%)或期数
+	Installment decimal.Decimal `json:"installment"` // 求解结果对应的每期还款金额
+	Response    *Response       `json:"response"`    // 求解结果对应的还款计划
+}
+
+/**
+  *@Description 反向求解：除 target 外的参数由 request 给出，求使每期还款金额不超过 installment 的最大贷款金额、最少期数，或使每期还款金额最接近 installment 的年利率
+**/
+func SolveRepaymentPlan(request *Request, target SolveTarget, installment decimal.Decimal) (*SolveResponse, error) {
+	if installment.LessThanOrEqual(decimal.Zero) {
+		return nil, newValidationError("installment", CodeInvalid, "target Installment error")
+	}
+	switch target {
+	case SolveLoanAmount:
+		return solveLoanAmount(*request, installment)
+	case SolveInterestRate:
+		return solveInterestRate(*request, installment)
+	case SolvePeriodNum:
+		if request.RepayMethod == BothPrincipalAndInterest {
+			return nil, newValidationError("target", CodeUnsupported, "solve target error: both principal and interest has only one period")
+		}
+		return solvePeriodNum(*request, installment)
+	}
+	return nil, newValidationError("target", CodeInvalid, "solve target error")
+}
+
+// 每期还款金额随贷款金额递增,二分法求每期还款金额不超过目标的最大贷款金额
+func solveLoanAmount(request Request, installment decimal.Decimal) (*SolveResponse, error) {
+	policy := request.RoundingPolicy
+	if err := checkRoundingPolicy(&policy); err != nil {
+		return nil, err
+	}
+	unit := decimal.New(1, -getRoundingScale(policy))
+
+	low := unit
+	if _, err := getSolveInstallment(request, func(r *Request) { r.LoanAmount = low }); err != nil {
+		return nil, err
+	}
+	high := installment
+	for i := 0; ; i++ {
+		amount, err := getSolveInstallment(request, func(r *Request) { r.LoanAmount = high })
+		if err != nil {
+			return nil, err
+		}
+		if amount.GreaterThan(installment) {
+			break
+		}
+		if i == maxSolveIterations {
+			return nil, errors.New("target installment can not be reached")
+		}
+		low, high = high, high.Mul(decimal.NewFromInt(2))
+	}
+	for i := 0; i < maxSolveIterations && high.Sub(low).GreaterThan(unit); i++ {
+		mid := roundAmount(low.Add(high).Div(decimal.NewFromInt(2)), RoundingPolicy{Mode: RoundingDown, Scale: policy.Scale})
+		if !mid.GreaterThan(low) {
+			break
+		}
+		amount, err := getSolveInstallment(request, func(r *Request) { r.LoanAmount = mid })
+		if err != nil {
+			return nil, err
+		}
+		if amount.GreaterThan(installment) {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return getSolveResponse(request, SolveLoanAmount, low, func(r *Request) { r.LoanAmount = low })
+}
+
+// 每期还款金额随年利率递增,二分法求每期还款金额最接近目标的年利率
+func solveInterestRate(request Request, installment decimal.Decimal) (*SolveResponse, error) {
+	low, high := decimal.New(1, -solveInterestRateScale), decimal.NewFromInt(maxSolveInterestRate)
+	lowAmount, err := getSolveInstallment(request, func(r *Request) { r.InterestRate = low })
+	if err != nil {
+		return nil, err
+	}
+	highAmount, err := getSolveInstallment(request, func(r *Request) { r.InterestRate = high })
+	if err != nil {
+		return nil, err
+	}
+	if lowAmount.GreaterThan(installment) || highAmount.LessThan(installment) {
+		return nil, errors.New("target installment can not be reached")
+	}
+	minStep := decimal.RequireFromString(solveInterestRateMinStep)
+	for i := 0; i < maxSolveIterations && high.Sub(low).GreaterThan(minStep); i++ {
+		mid := low.Add(high).Div(decimal.NewFromInt(2))
+		amount, err := getSolveInstallment(request, func(r *Request) { r.InterestRate = mid })
+		if err != nil {
+			return nil, err
+		}
+		if amount.GreaterThan(installment) {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	rate := low.Add(high).Div(decimal.NewFromInt(2)).Round(solveInterestRateScale)
+	return getSolveResponse(request, SolveInterestRate, rate, func(r *Request) { r.InterestRate = rate })
+}
+
+// 每期还款金额随期数递减,二分法求每期还款金额不超过目标的最少期数
+func solvePeriodNum(request Request, installment decimal.Decimal) (*SolveResponse, error) {
+	// 按还款周期的期数求解 上限与参数检查的总期数上限一致
+	setPeriodNum := func(periodNum int) func(r *Request) {
+		return func(r *Request) {
+			r.PeriodNum = periodNum
+			r.PeriodType = PeriodTypeMonth
+			r.LoanEndDate = ""
+		}
+	}
+	amount, err := getSolveInstallment(request, setPeriodNum(MaxPeriodNum))
+	if err != nil {
+		return nil, err
+	}
+	if amount.GreaterThan(installment) {
+		return nil, errors.New("target installment can not be reached")
+	}
+	low, high := 1, MaxPeriodNum
+	for low < high {
+		mid := (low + high) / 2
+		amount, err = getSolveInstallment(request, setPeriodNum(mid))
+		if err != nil {
+			return nil, err
+		}
+		if amount.GreaterThan(installment) {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return getSolveResponse(request, SolvePeriodNum, decimal.NewFromInt(int64(low)), setPeriodNum(low))
+}
+
+// 按求解结果生成完整的还款计划 包括实际年化利率
+func getSolveResponse(request Request, target SolveTarget, value decimal.Decimal, set func(r *Request)) (*SolveResponse, error) {
+	set(&request)
+	response, err := CalculateRepaymentPlan(&request)
+	if err != nil {
+		return nil, err
+	}
+	return &SolveResponse{Target: target, Value: value, Installment: getPlanInstallment(response), Response: response}, nil
+}
+
+// 按修改后的参数生成各期还款金额,不计算实际年化利率;每次使用请求的副本,避免生成还款计划时填充的默认值影响下一次计算
+func getSolveInstallment(request Request, set func(r *Request)) (decimal.Decimal, error) {
+	set(&request)
+	if err := check(&request); err != nil {
+		return decimal.Zero, err
+	}
+	response, err := getRepaymentSchedule(&request)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return getPlanInstallment(response), nil
+}
+
+// 还款计划的每期还款金额:除最后一期外各期的最高还款金额,只有一期时为该期还款金额
+func getPlanInstallment(response *Response) decimal.Decimal {
+	records := response.PlanRepayRecords
+	if len(records) == 1 {
+		return records[0].PeriodRepayTotalAmount
+	}
+	installment := decimal.Zero
+	for _, record := range records[:len(records)-1] {
+		installment = decimal.Max(installment, record.PeriodRepayTotalAmount)
+	}
+	return installment
+}
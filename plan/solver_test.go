@@ -0,0 +1,99 @@
+package plan
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+/**
+  *@Description 反向求解 等额本息月供5000可贷金额、月供2122.91对应的年利率、等额本金每期最高还款不超过12000的最少期数
+**/
+func Test_solveRepaymentPlan(t *testing.T) {
+	cases := []struct {
+		target       SolveTarget
+		repayMethod  RepayMethod
+		loanAmount   string
+		interestRate string
+		installment  string
+		want         string
+		tolerance    string
+	}{
+		// 5000*((1+r)^360-1)/(r*(1+r)^360) r=4.9%/12
+		{SolveLoanAmount, EqualLoanRepayment, "0", "4.9", "5000", "942104.44", "5"},
+		{SolveInterestRate, EqualLoanRepayment, "400000", "0", "2122.91", "4.9", "0"},
+		// 第一期 120000/n+120000*31*4.9%/360 <= 12000
+		{SolvePeriodNum, EqualPrincipalRepayment, "120000", "4.9", "12000", "11", "0"},
+	}
+	for _, c := range cases {
+		request := &Request{
+			LoanAmount:    decimal.RequireFromString(c.loanAmount),
+			LoanStartDate: "2022-01-01",
+			InterestRate:  decimal.RequireFromString(c.interestRate),
+			PeriodNum:     360,
+			RepayDay:      1,
+			LoanCycleCode: LoanCycleMonthly,
+			RepayMethod:   c.repayMethod,
+			PeriodType:    PeriodTypeMonth,
+		}
+		installment := decimal.RequireFromString(c.installment)
+		resp, err := SolveRepaymentPlan(request, c.target, installment)
+		if err != nil {
+			t.Errorf("%s: %v", c.target, err)
+			continue
+		}
+		if resp.Value.Sub(decimal.RequireFromString(c.want)).Abs().GreaterThan(decimal.RequireFromString(c.tolerance)) {
+			t.Errorf("%s: got %s, want %s", c.target, resp.Value, c.want)
+		}
+		if resp.Installment.GreaterThan(installment) || resp.Response == nil {
+			t.Errorf("%s: got installment %s, want at most %s", c.target, resp.Installment, installment)
+		}
+		if c.target == SolveLoanAmount && !resp.Response.LoanAmount.Equal(resp.Value) ||
+			c.target == SolvePeriodNum && !decimal.NewFromInt(int64(resp.Response.TotalPeriodNum)).Equal(resp.Value) {
+			t.Errorf("%s: plan does not match %s", c.target, resp.Value)
+		}
+		// 求解不修改原请求
+		if !request.LoanAmount.Equal(decimal.RequireFromString(c.loanAmount)) || request.PeriodNum != 360 || request.LoanEndDate != "" {
+			t.Errorf("%s: request modified %+v", c.target, request)
+		}
+	}
+}
+
+/**
+  *@Description 按日还款求解期数 结果超过5年 第一日 100000/n+100000*4.9%/360 <= 60 n=2156
+**/
+func Test_solvePeriodNumDaily(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromInt(100000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(4.9),
+		LoanCycleCode: LoanCycleDaily,
+		RepayMethod:   EqualPrincipalRepayment,
+		PeriodType:    PeriodTypeYear,
+		PeriodNum:     1,
+	}
+	resp, err := SolveRepaymentPlan(request, SolvePeriodNum, decimal.NewFromInt(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Value.Equal(decimal.NewFromInt(2156)) || resp.Response.TotalPeriodNum != 2156 || resp.Installment.GreaterThan(decimal.NewFromInt(60)) {
+		t.Errorf("got %s periods %d installment %s, want 2156", resp.Value, resp.Response.TotalPeriodNum, resp.Installment)
+	}
+	if resp.Response.AnnualPercentageRate.IsZero() {
+		t.Error("solve response without annual percentage rate")
+	}
+}
+
+func Test_solvePeriodNumUnreachable(t *testing.T) {
+	request := &Request{
+		LoanAmount:    decimal.NewFromInt(120000),
+		LoanStartDate: "2022-01-01",
+		InterestRate:  decimal.NewFromFloat(4.9),
+		RepayDay:      1,
+		LoanCycleCode: LoanCycleMonthly,
+		RepayMethod:   EqualPrincipalRepayment,
+		PeriodType:    PeriodTypeMonth,
+	}
+	if _, err := SolveRepaymentPlan(request, SolvePeriodNum, decimal.NewFromInt(100)); err == nil {
+		t.Error("expected error for unreachable installment")
+	}
+}
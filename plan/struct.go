@@ -1,51 +1,101 @@
-package main
+package plan
 
 import (
 	"github.com/shopspring/decimal"
 	"time"
 )
 
+// Request 还款计划请求参数
 type Request struct {
-	LoanAmount    decimal.Decimal `json:"loanAmount" validate:"required"`    // 贷款金额
-	LoanStartDate string          `json:"loanStartDate" validate:"required"` // 利息计算开始日期
-	LoanEndDate   string          `json:"loanEndDate"`                       // 利息计算结束日期
-	LoanCycleCode string          `json:"loanCycleCode"`                     // 还款周期频率 01-日 02-两周 03-月 04-季 05-年
-	InterestRate  decimal.Decimal `json:"interestRate" validate:"required"`  // 年利率
-	RepayMethod   string          `json:"repayMethod" validate:"required"`   // 还款方式:1-等额本息  2-等额本金  3-利随本清 4-先息后本 5-等本等息
-	PeriodNum     int             `json:"periodNum"`                         // 期数
-	PeriodType    string          `json:"periodType"`                        // 期数类型 01-年 02-月
-	RepayDay      int             `json:"repayDay"`                          // 每一期还款日
-	DaysOfYear    int             `json:"daysOfYear"`                        // 年天数 默认360
+	LoanAmount            decimal.Decimal       `json:"loanAmount" validate:"required"`    // 贷款金额
+	LoanStartDate         string                `json:"loanStartDate" validate:"required"` // 利息计算开始日期
+	LoanEndDate           string                `json:"loanEndDate"`                       // 利息计算结束日期
+	LoanCycleCode         LoanCycleCode         `json:"loanCycleCode"`                     // 还款周期频率 01-日 02-两周 03-月 04-季 05-年
+	InterestRate          decimal.Decimal       `json:"interestRate" validate:"required"`  // 年利率
+	RepayMethod           RepayMethod           `json:"repayMethod" validate:"required"`   // 还款方式:1-等额本息  2-等额本金  3-利随本清 4-先息后本 5-等本等息 6-气球贷 7-阶梯还款 8-78法则 9-自定义本金
+	PeriodNum             int                   `json:"periodNum"`                         // 期数
+	PeriodType            PeriodType            `json:"periodType"`                        // 期数类型 01-年 02-月
+	RepayDay              int                   `json:"repayDay"`                          // 每一期还款日 按日还款时不需要
+	RepayMonthOfQuarter   int                   `json:"repayMonthOfQuarter"`               // 按季还款时每季的第几个月还款 1-3 默认3
+	DaysOfYear            int                   `json:"daysOfYear"`                        // 年天数 默认360
+	DayCountConvention    DayCountConvention    `json:"dayCountConvention"`                // 计息基准 ACT/360 ACT/365F ACT/ACT ISDA 30/360 30E/360,设置后覆盖年天数
+	RoundingPolicy        RoundingPolicy        `json:"roundingPolicy"`                    // 舍入规则 默认四舍五入保留两位小数,尾差放在最后一期
+	RateSchedule          []RateReset           `json:"rateSchedule"`                      // 利率调整计划 InterestRate为初始利率
+	FloatingRate          *FloatingRate         `json:"floatingRate"`                      // 浮动利率 InterestRate为初始利率,与利率调整计划不能同时设置
+	Calendar              HolidayCalendar       `json:"-"`                                 // 节假日日历
+	BusinessDayConvention BusinessDayConvention `json:"businessDayConvention"`             // 还款日遇节假日的调整方式 默认不调整
+	AdjustInterestEndDate bool                  `json:"adjustInterestEndDate"`             // 计息结束日是否随还款日调整
+	GracePeriodNum        int                   `json:"gracePeriodNum"`                    // 宽限期期数 包含在期数内,仅等额本息和等额本金
+	GraceType             GraceType             `json:"graceType"`                         // 宽限期类型 1-只还利息 2-利息计入本金 默认1
+	BalloonAmount         decimal.Decimal       `json:"balloonAmount"`                     // 尾款金额 气球贷最后一期归还,与尾款比例设置其一
+	BalloonPercent        decimal.Decimal       `json:"balloonPercent"`                    // 尾款比例(%) 尾款=贷款金额*尾款比例
+	StepPeriodNum         int                   `json:"stepPeriodNum"`                     // 阶梯还款每隔几期调整一次每期还款金额 默认一年的期数
+	StepRate              decimal.Decimal       `json:"stepRate"`                          // 阶梯还款每次调整的比例(%) 负数为递减,与调整金额设置其一
+	StepAmount            decimal.Decimal       `json:"stepAmount"`                        // 阶梯还款每次调整的金额 负数为递减
+	PrincipalSchedule     []PrincipalRepayment  `json:"principalSchedule"`                 // 自定义本金计划 各期本金之和必须等于贷款金额(含计入本金的费用)
+	UpfrontFee            decimal.Decimal       `json:"upfrontFee"`                        // 放款时收取的手续费 计算实际年化利率时从放款金额中扣除
+	FeeSchedules          []FeeSchedule         `json:"feeSchedules"`                      // 费用计划 放款时、每期或指定期次收取的费用
 }
 
+// Response 还款计划
 type Response struct {
-	RepayMethod      string            `json:"repayMethod"`            // 还款方式:1-等额本息  2-等额本金  3-利随本清 4-先息后本 5-等本等息
-	LoanStartDate    string            `json:"loanStartDate"`          // 利息计算开始日期
-	LoanEndDate      string            `json:"loanEndDate"`            // 利息计算结束日期
-	TotalPeriodNum   int               `json:"totalPeriodNum"`         // 期数
-	TotalRepayAmount decimal.Decimal   `json:"totalRepayAmount"`       // 总还款金额
-	LoanAmount       decimal.Decimal   `json:"loanAmount"`             // 贷款金额
-	TotalInterest    decimal.Decimal   `json:"planRepayTotalInterest"` // 总还款利息
-	InterestRate     decimal.Decimal   `json:"interestRate"`           // 年利率
-	PlanRepayRecords []RepayPlanRecord `json:"planRepayRecords"`       // 还款计划
+	RepayMethod           RepayMethod           `json:"repayMethod"`            // 还款方式:1-等额本息  2-等额本金  3-利随本清 4-先息后本 5-等本等息 6-气球贷 7-阶梯还款 8-78法则 9-自定义本金
+	LoanStartDate         string                `json:"loanStartDate"`          // 利息计算开始日期
+	LoanEndDate           string                `json:"loanEndDate"`            // 利息计算结束日期
+	TotalPeriodNum        int                   `json:"totalPeriodNum"`         // 期数
+	TotalRepayAmount      decimal.Decimal       `json:"totalRepayAmount"`       // 总还款金额
+	LoanAmount            decimal.Decimal       `json:"loanAmount"`             // 贷款金额
+	TotalInterest         decimal.Decimal       `json:"planRepayTotalInterest"` // 总还款利息
+	InterestRate          decimal.Decimal       `json:"interestRate"`           // 年利率
+	LoanCycleCode         LoanCycleCode         `json:"loanCycleCode"`          // 还款周期频率
+	DaysOfYear            int                   `json:"daysOfYear"`             // 年天数
+	DayCountConvention    DayCountConvention    `json:"dayCountConvention"`     // 计息基准
+	RoundingPolicy        RoundingPolicy        `json:"roundingPolicy"`         // 舍入规则
+	RateSchedule          []RateReset           `json:"rateSchedule"`           // 利率调整计划(浮动利率按重定价日展开)
+	BusinessDayConvention BusinessDayConvention `json:"businessDayConvention"`  // 还款日调整方式
+	GracePeriodNum        int                   `json:"gracePeriodNum"`         // 宽限期期数
+	GraceType             GraceType             `json:"graceType"`              // 宽限期类型
+	BalloonAmount         decimal.Decimal       `json:"balloonAmount"`          // 尾款金额
+	StepPeriodNum         int                   `json:"stepPeriodNum"`          // 阶梯还款每隔几期调整一次
+	StepRate              decimal.Decimal       `json:"stepRate"`               // 阶梯还款每次调整的比例(%)
+	StepAmount            decimal.Decimal       `json:"stepAmount"`             // 阶梯还款每次调整的金额
+	PrincipalSchedule     []PrincipalRepayment  `json:"principalSchedule"`      // 自定义本金计划
+	UpfrontFee            decimal.Decimal       `json:"upfrontFee"`             // 放款时收取的手续费 包括费用计划中放款时收取的和计入本金的费用
+	FinancedFee           decimal.Decimal       `json:"financedFee"`            // 计入贷款本金的费用 已包含在贷款金额中
+	TotalFees             decimal.Decimal       `json:"totalFees"`              // 费用合计=放款时收取的手续费+各期费用
+	FeeSchedules          []FeeSchedule         `json:"feeSchedules"`           // 费用计划
+	PeriodIRR             decimal.Decimal       `json:"periodIrr"`              // 每期内部收益率(%)
+	AnnualPercentageRate  decimal.Decimal       `json:"annualPercentageRate"`   // 年化利率APR(%)=每期内部收益率×每年期数
+	EffectiveAnnualRate   decimal.Decimal       `json:"effectiveAnnualRate"`    // 实际年利率EAR(%)=(1+每期内部收益率)^每年期数-1
+	PlanRepayRecords      []RepayPlanRecord     `json:"planRepayRecords"`       // 还款计划
 }
+
+// RepayPlanRecord 每一期的还款计划
 type RepayPlanRecord struct {
-	PeriodNum              int             `json:"periodNum"`              // 期次
-	PeriodStartDate        string          `json:"periodStartDate"`        // 本期开始日期
-	PeriodEndDate          string          `json:"periodEndDate"`          // 本期结束日期
-	DaysOfPeriod           int             `json:"daysOfPeriod"`           // 本期天数
-	PeriodRepayDate        string          `json:"periodRepayDate"`        // 本期还款日期
-	PeriodRepayTotalAmount decimal.Decimal `json:"periodRepayTotalAmount"` // 本期还款总金额
-	PeriodRepayPrinciple   decimal.Decimal `json:"periodRepayPrinciple"`   // 本期还款本金
-	PeriodRepayInterest    decimal.Decimal `json:"periodRepayInterest"`    // 本期还款利息
-	MaintainPrinciple      decimal.Decimal `json:"maintainPrinciple"`      // 剩余还款金额
+	PeriodNum              int                `json:"periodNum"`              // 期次
+	PeriodStartDate        string             `json:"periodStartDate"`        // 本期开始日期
+	PeriodEndDate          string             `json:"periodEndDate"`          // 本期结束日期
+	DaysOfPeriod           int                `json:"daysOfPeriod"`           // 本期天数
+	PeriodRepayDate        string             `json:"periodRepayDate"`        // 本期还款日期
+	PeriodRepayTotalAmount decimal.Decimal    `json:"periodRepayTotalAmount"` // 本期还款总金额
+	PeriodRepayPrinciple   decimal.Decimal    `json:"periodRepayPrinciple"`   // 本期还款本金
+	PeriodRepayInterest    decimal.Decimal    `json:"periodRepayInterest"`    // 本期还款利息
+	MaintainPrinciple      decimal.Decimal    `json:"maintainPrinciple"`      // 剩余还款金额
+	DayCountConvention     DayCountConvention `json:"dayCountConvention"`     // 计息基准
+	InterestRate           decimal.Decimal    `json:"interestRate"`           // 本期年利率,期内利率调整时为调整后的利率
+	IsPrepayment           bool               `json:"isPrepayment"`           // 是否为提前还款
+	IsGracePeriod          bool               `json:"isGracePeriod"`          // 是否为宽限期
+	CapitalizedInterest    decimal.Decimal    `json:"capitalizedInterest"`    // 本期计入本金的利息
+	BalloonAmount          decimal.Decimal    `json:"balloonAmount"`          // 本期归还的尾款
+	PeriodRepayFee         decimal.Decimal    `json:"periodRepayFee"`         // 本期费用 已计入本期还款总金额
+	Fees                   []PeriodFee        `json:"fees,omitempty"`         // 本期费用明细
 }
 
 type repayPlanRequest struct {
 	LoanAmount              decimal.Decimal // 贷款金额
 	LoanStartDate           string          // 利息计算开始日期=开始贷款日期
 	LoanEndDate             string          // 利息计算结束日期=最后一次还款日
-	LoanCycleCode           string          // 还款周期频率 01-daily 日 02-fortnightly 两周 03-monthly 月 04-quarterly 季 05-yearly 年
+	LoanCycleCode           LoanCycleCode   // 还款周期频率 01-daily 日 02-fortnightly 两周 03-monthly 月 04-quarterly 季 05-yearly 年
 	PeriodInterestRate      decimal.Decimal // 期利率
 	TotalPeriodNum          int             // 总期数
 	FirstRepayDate          time.Time       // 首个还款日
@@ -53,4 +103,20 @@ type repayPlanRequest struct {
 	LoanEndDateParseLocal   time.Time
 	RepayDay                int
 	DaysInterestRate        decimal.Decimal
+	InterestRate            decimal.Decimal       // 年利率
+	DaysOfYear              int                   // 年天数
+	DayCountConvention      DayCountConvention    // 计息基准
+	RoundingPolicy          RoundingPolicy        // 舍入规则
+	RateCurve               []rateCurvePoint      // 利率曲线 为空时为固定利率
+	Calendar                HolidayCalendar       // 节假日日历
+	BusinessDayConvention   BusinessDayConvention // 还款日调整方式
+	AdjustInterestEndDate   bool                  // 计息结束日是否随还款日调整
+	PeriodDates             map[int][]time.Time   // 各期的开始计息日、结束计息日和还款日(已按节假日调整)
+	GracePeriodNum          int                   // 宽限期期数
+	GraceType               GraceType             // 宽限期类型
+	BalloonAmount           decimal.Decimal       // 尾款金额
+	StepPeriodNum           int                   // 阶梯还款每隔几期调整一次
+	StepRate                decimal.Decimal       // 阶梯还款每次调整的比例(%)
+	StepAmount              decimal.Decimal       // 阶梯还款每次调整的金额
+	PrincipalSchedule       []PrincipalRepayment  // 自定义本金计划
 }
@@ -1,20 +1,19 @@
-package main
+package plan
 
 import (
-	"errors"
 	"github.com/shopspring/decimal"
 	"time"
 )
 
 // 获取第一个还款日
 func getFirstRepayDate(request *Request, loanStartDateParseLocal time.Time) (time.Time, error) {
-	nextRepayDate := calculateFirstRepayDate(loanStartDateParseLocal, request.LoanCycleCode, request.RepayDay)
+	nextRepayDate := calculateFirstRepayDate(loanStartDateParseLocal, request.LoanCycleCode, request.RepayDay, request.RepayMonthOfQuarter)
 
 	// 如果下一还款日比到期日还大，则下一还款日就是到期日
 	if request.LoanEndDate != "" {
 		loanEndDateParseLocal, e := time.ParseInLocation(DATE_DASH_FORMAT, request.LoanEndDate, time.Local)
 		if nil != e {
-			return time.Time{}, errors.New("interest Calculate End Date error")
+			return time.Time{}, newValidationError("loanEndDate", CodeInvalid, "interest Calculate End Date error")
 		}
 		if nextRepayDate.After(loanEndDateParseLocal) {
 			return loanEndDateParseLocal, nil
@@ -24,12 +23,18 @@ func getFirstRepayDate(request *Request, loanStartDateParseLocal time.Time) (tim
 }
 
 // 计算第一个还款日
-func calculateFirstRepayDate(loanStartDateParseLocal time.Time, loanCycleCode string, repayDay int) time.Time {
+func calculateFirstRepayDate(loanStartDateParseLocal time.Time, loanCycleCode LoanCycleCode, repayDay, repayMonthOfQuarter int) time.Time {
 	switch loanCycleCode {
-	case loanCycleFortnightly:
+	case LoanCycleDaily:
+		return loanStartDateParseLocal.AddDate(0, 0, 1)
+	case LoanCycleFortnightly:
 		return getFirstRepayDateOfLoanCycleFortnightly(repayDay, loanStartDateParseLocal)
-	case loanCycleMonthly:
+	case LoanCycleMonthly:
 		return getFirstRepayDateOfLoanCycleMonthly(repayDay, loanStartDateParseLocal)
+	case LoanCycleQuarterly:
+		return getFirstRepayDateOfLoanCycleQuarterly(repayDay, repayMonthOfQuarter, loanStartDateParseLocal)
+	case LoanCycleYearly:
+		return getFirstRepayDateOfMonthsCycle(repayDay, 0, numberOfMonth, loanStartDateParseLocal)
 	}
 
 	return time.Time{}
@@ -70,60 +75,123 @@ func getFirstRepayDateOfLoanCycleMonthly(repayDay int, loanStartDateParseLocal t
 	return nextRepayDate
 }
 
-func getTotalPeriodNum(request *Request, loanStartDateParseLocal time.Time) (totalPeriodNum int, err error) {
+// 按季还款:每季的第 repayMonthOfQuarter 个月的还款日
+func getFirstRepayDateOfLoanCycleQuarterly(repayDay, repayMonthOfQuarter int, loanStartDateParseLocal time.Time) time.Time {
+	startMonth := int(loanStartDateParseLocal.Month())
+	// 起息日所在季度的还款月与起息月相差的月数
+	monthsNum := (startMonth-1)/numberOfMonthsOfQuarter*numberOfMonthsOfQuarter + repayMonthOfQuarter - startMonth
+	return getFirstRepayDateOfMonthsCycle(repayDay, monthsNum, numberOfMonthsOfQuarter, loanStartDateParseLocal)
+}
+
+// 按 monthsOfCycle 个月为一期:起息日之后的第一个还款日,首期不足每期月数×20天的顺延一期
+func getFirstRepayDateOfMonthsCycle(repayDay, monthsNum, monthsOfCycle int, loanStartDateParseLocal time.Time) time.Time {
+	nextRepayDate := calculateDateAddMonth(loanStartDateParseLocal, monthsNum, repayDay)
+	if !nextRepayDate.After(loanStartDateParseLocal) {
+		nextRepayDate = calculateDateAddMonth(loanStartDateParseLocal, monthsNum+monthsOfCycle, repayDay)
+	}
+
+	// 与按月还款的20天一致,按季为60天,按年为240天
+	if (nextRepayDate.Sub(loanStartDateParseLocal)).Hours() < float64(20*24*monthsOfCycle) {
+		// 小于每期月数×20天 取下一期
+		nextRepayDate = calculateDateAddMonth(nextRepayDate, monthsOfCycle, repayDay)
+	}
+	return nextRepayDate
+}
+
+// 每期间隔的月数,非按月计算的周期返回0
+func getMonthsOfLoanCycle(loanCycleCode LoanCycleCode) int {
+	switch loanCycleCode {
+	case LoanCycleMonthly:
+		return 1
+	case LoanCycleQuarterly:
+		return numberOfMonthsOfQuarter
+	case LoanCycleYearly:
+		return numberOfMonth
+	}
+	return 0
+}
+
+func getTotalPeriodNum(request *Request, loanStartDateParseLocal, firstRepayDate time.Time) (totalPeriodNum int, err error) {
 	if request.PeriodNum == 0 {
-		totalPeriodNum, err = calculateTotalPeriodNum(request.LoanCycleCode, loanStartDateParseLocal, request.LoanEndDate, request.RepayDay)
+		totalPeriodNum, err = calculateTotalPeriodNum(request.LoanCycleCode, loanStartDateParseLocal, firstRepayDate, request.LoanEndDate, request.RepayDay)
 		if nil != err {
 			return 0, err
 		}
 	} else {
-		if request.PeriodType == periodTypeYear {
-			totalPeriodNum = 12 * request.PeriodNum
+		if request.PeriodType == PeriodTypeYear {
+			totalPeriodNum = calculatePeriodNumOfYears(request.LoanCycleCode, loanStartDateParseLocal, request.PeriodNum)
 		} else {
 			totalPeriodNum = request.PeriodNum
 		}
 	}
 	return totalPeriodNum, nil
 }
-func calculateTotalPeriodNum(loanCycleCode string, loanStartDateParseLocal time.Time, loanEndDate string, repayDay int) (int, error) {
+
+// 期数类型为年时换算成还款周期的期数
+func calculatePeriodNumOfYears(loanCycleCode LoanCycleCode, loanStartDateParseLocal time.Time, years int) int {
+	switch loanCycleCode {
+	case LoanCycleDaily:
+		return int(getDaysBetweenDate(loanStartDateParseLocal, loanStartDateParseLocal.AddDate(years, 0, 0))) - 1
+	case LoanCycleFortnightly:
+		return numberOfWeek * years
+	case LoanCycleQuarterly:
+		return numberOfQuarter * years
+	case LoanCycleYearly:
+		return years
+	}
+	return numberOfMonth * years
+}
+
+func calculateTotalPeriodNum(loanCycleCode LoanCycleCode, loanStartDateParseLocal, firstRepayDate time.Time, loanEndDate string, repayDay int) (int, error) {
 	period := 1
 	loanEndDateParseLocal, err := time.ParseInLocation(DATE_DASH_FORMAT, loanEndDate, time.Local)
 	if err != nil {
-		return 0, errors.New("loanEndDate date format error: " + err.Error())
+		return 0, newValidationError("loanEndDate", CodeInvalid, "loanEndDate date format error: "+err.Error())
 	}
 	switch loanCycleCode {
-	case loanCycleFortnightly:
+	case LoanCycleDaily:
+		// 每天一期:起息日至到期日的天数
+		period = int(getDaysBetweenDate(loanStartDateParseLocal, loanEndDateParseLocal)) - 1
+	case LoanCycleFortnightly:
 		cycle := 14
 		for {
 			// accumulate period 14 days 累加period个14天
 			repayDateAddCycle := loanStartDateParseLocal.AddDate(0, 0, period*cycle)
 			// when after repayDate add cycle less or equal maturityDate,finish
 			// 累加后的日期小于maturityDate,结束循环，返回 period
-			if repayDateAddCycle.After(loanEndDateParseLocal) || repayDateAddCycle.Equal(loanEndDateParseLocal) {
+			if repayDateAddCycle.After(loanEndDateParseLocal) || repayDateAddCycle.Equal(loanEndDateParseLocal) || period > MaxPeriodNum {
 				break
 			}
 			period = period + 1
 		}
-	case loanCycleMonthly:
+	case LoanCycleMonthly:
 		for {
 			repayDate := calculateDateAddMonth(loanStartDateParseLocal, period, repayDay)
-			if repayDate.After(loanEndDateParseLocal) || repayDate.Equal(loanEndDateParseLocal) {
+			if repayDate.After(loanEndDateParseLocal) || repayDate.Equal(loanEndDateParseLocal) || period > MaxPeriodNum {
 				break
 			}
 			period = period + 1
 		}
+	case LoanCycleQuarterly, LoanCycleYearly:
+		// 从首个还款日开始累加period个周期,直到还款日不早于到期日
+		monthsOfCycle := getMonthsOfLoanCycle(loanCycleCode)
+		for repayDate := firstRepayDate; repayDate.Before(loanEndDateParseLocal) && period <= MaxPeriodNum; period++ {
+			repayDate = calculateDateAddMonth(firstRepayDate, period*monthsOfCycle, repayDay)
+		}
 	}
 	return period, nil
 }
 
-func getLoanEndDate(request *Request, firstRepayDate time.Time) error {
+func getLoanEndDate(request *Request, firstRepayDate time.Time, totalPeriodNum int) error {
 	if request.LoanEndDate == "" || (request.PeriodNum != 0 && request.LoanEndDate != "") {
 		var loanEndDateParseLocal time.Time
 		switch request.LoanCycleCode {
-		case loanCycleFortnightly:
-			loanEndDateParseLocal = calculateLoanEndDateWithLoanCycleFortnightly(firstRepayDate, request.PeriodNum-1)
-		case loanCycleMonthly:
-			loanEndDateParseLocal = calculateDateAddMonth(firstRepayDate, request.PeriodNum-1, request.RepayDay)
+		case LoanCycleDaily:
+			loanEndDateParseLocal = firstRepayDate.AddDate(0, 0, totalPeriodNum-1)
+		case LoanCycleFortnightly:
+			loanEndDateParseLocal = calculateLoanEndDateWithLoanCycleFortnightly(firstRepayDate, totalPeriodNum-1)
+		case LoanCycleMonthly, LoanCycleQuarterly, LoanCycleYearly:
+			loanEndDateParseLocal = calculateDateAddMonth(firstRepayDate, (totalPeriodNum-1)*getMonthsOfLoanCycle(request.LoanCycleCode), request.RepayDay)
 		}
 		request.LoanEndDate = loanEndDateParseLocal.Format(DATE_DASH_FORMAT)
 	}
@@ -149,12 +217,18 @@ func calculateDateAddMonth(date time.Time, monthsNum, day int) time.Time {
 	return firstDayAddMonthAddDay
 }
 
-func calculatePeriodInterestRate(interestRate decimal.Decimal, loanCycleCode string) decimal.Decimal {
+func calculatePeriodInterestRate(interestRate decimal.Decimal, loanCycleCode LoanCycleCode, daysOfYear int) decimal.Decimal {
 	switch loanCycleCode {
-	case loanCycleFortnightly:
+	case LoanCycleDaily:
+		return calculateDaysInterestRate(interestRate, daysOfYear)
+	case LoanCycleFortnightly:
 		return interestRate.Div(decimal.NewFromFloat(float64(numberOfWeek))).Div(decimal.NewFromFloat(100))
-	case loanCycleMonthly:
+	case LoanCycleMonthly:
 		return interestRate.Div(decimal.NewFromInt(int64(numberOfMonth))).Div(decimal.NewFromFloat(100))
+	case LoanCycleQuarterly:
+		return interestRate.Div(decimal.NewFromInt(int64(numberOfQuarter))).Div(decimal.NewFromFloat(100))
+	case LoanCycleYearly:
+		return interestRate.Div(decimal.NewFromFloat(100))
 	}
 	return decimal.Decimal{}
 }
@@ -191,8 +265,8 @@ func getDaysBetweenDate(startDate, endDate time.Time) int64 {
 	return date
 }
 
-// calculate every period startDate,endDate,repayDate
-func calculatePeriodDate(request repayPlanRequest) map[int][]time.Time {
+// calculate every period startDate,endDate,repayDate 各期的开始计息日、结束计息日和还款日,还款日无法调整到工作日时返回错误
+func calculatePeriodDate(request repayPlanRequest) (map[int][]time.Time, error) {
 	dateMap := make(map[int][]time.Time)
 	for i := 0; i < request.TotalPeriodNum; i++ {
 		periodStartDate := time.Time{} // 计息开始日
@@ -202,13 +276,17 @@ func calculatePeriodDate(request repayPlanRequest) map[int][]time.Time {
 			periodStartDate = request.LoanStartDateParseLocal
 			periodRepayDate = request.FirstRepayDate
 		} else {
-			if request.LoanCycleCode == loanCycleFortnightly {
+			if request.LoanCycleCode == LoanCycleFortnightly {
 				periodStartDate = dateMap[i-1][2]
 				repaymentTs := request.FirstRepayDate.AddDate(0, 0, i*14)
 				periodRepayDate = time.Unix(repaymentTs.Unix(), 0)
 			}
-			if request.LoanCycleCode == loanCycleMonthly {
-				periodRepayDateTemp := calculateDateAddMonth(request.FirstRepayDate, i, request.RepayDay)
+			if request.LoanCycleCode == LoanCycleDaily {
+				periodStartDate = dateMap[i-1][2]
+				periodRepayDate = request.FirstRepayDate.AddDate(0, 0, i)
+			}
+			if monthsOfCycle := getMonthsOfLoanCycle(request.LoanCycleCode); monthsOfCycle > 0 {
+				periodRepayDateTemp := calculateDateAddMonth(request.FirstRepayDate, i*monthsOfCycle, request.RepayDay)
 				periodRepayDate = periodRepayDateTemp
 				periodStartDate = dateMap[i-1][2]
 			}
@@ -221,5 +299,52 @@ func calculatePeriodDate(request repayPlanRequest) map[int][]time.Time {
 		periodEndDate := periodRepayDate.AddDate(0, 0, -1)
 		dateMap[i] = []time.Time{periodStartDate, periodEndDate, periodRepayDate}
 	}
-	return dateMap
+	// 还款日遇节假日调整
+	if err := adjustPeriodDate(request, dateMap); err != nil {
+		return nil, err
+	}
+	return dateMap, nil
+}
+
+// 填充还款计划并累积总还款金额和总利息
+func fillRepayPlanRecords(response *Response, records []RepayPlanRecord) {
+	var sumTotalInterest, sumTotalRepayAmount decimal.Decimal
+	for _, record := range records {
+		// 累积还款总金额
+		sumTotalRepayAmount = sumTotalRepayAmount.Add(record.PeriodRepayTotalAmount)
+		// 累积还款总利息,包括计入本金的利息
+		sumTotalInterest = sumTotalInterest.Add(record.PeriodRepayInterest).Add(record.CapitalizedInterest)
+	}
+	response.PlanRepayRecords = records
+	response.TotalRepayAmount = sumTotalRepayAmount
+	response.TotalInterest = sumTotalInterest
+}
+
+// 根据已生成的还款计划构造剩余本金 loanAmount 自 startDate 起的还款计划参数
+func getResponsePlanRequest(response *Response, loanAmount decimal.Decimal, startDate time.Time) (repayPlanRequest, error) {
+	days := response.DaysOfYear
+	if days == 0 {
+		days = daysOfYear
+	}
+	rateCurve, err := parseRateCurve(response.RateSchedule)
+	if err != nil {
+		return repayPlanRequest{}, err
+	}
+	request := repayPlanRequest{
+		LoanAmount:              loanAmount,
+		LoanStartDate:           startDate.Format(DATE_DASH_FORMAT),
+		LoanEndDate:             response.LoanEndDate,
+		LoanCycleCode:           response.LoanCycleCode,
+		LoanStartDateParseLocal: startDate,
+		InterestRate:            response.InterestRate,
+		DaysOfYear:              days,
+		DayCountConvention:      response.DayCountConvention,
+		RoundingPolicy:          response.RoundingPolicy,
+		RateCurve:               rateCurve,
+	}
+	// 期利率和日利率按 startDate 执行的利率计算
+	interestRate := getInterestRateOf(request, startDate)
+	request.PeriodInterestRate = calculatePeriodInterestRate(interestRate, response.LoanCycleCode, days)
+	request.DaysInterestRate = calculateDaysInterestRate(interestRate, days)
+	return request, nil
 }
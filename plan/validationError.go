@@ -0,0 +1,99 @@
+package plan
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorCode 参数错误码
+type ErrorCode string
+
+// 参数错误码
+const (
+	CodeRequired    ErrorCode = "REQUIRED"    // 缺少必填参数
+	CodeInvalid     ErrorCode = "INVALID"     // 取值或格式错误
+	CodeConflict    ErrorCode = "CONFLICT"    // 与其他参数冲突
+	CodeUnsupported ErrorCode = "UNSUPPORTED" // 还款方式或还款周期不支持该参数
+)
+
+// 与错误码对应的哨兵错误,可用 errors.Is 判断参数错误的类别
+var (
+	ErrRequired    = errors.New("required")
+	ErrInvalid     = errors.New("invalid")
+	ErrConflict    = errors.New("conflict")
+	ErrUnsupported = errors.New("unsupported")
+)
+
+var codeErrors = map[ErrorCode]error{
+	CodeRequired:    ErrRequired,
+	CodeInvalid:     ErrInvalid,
+	CodeConflict:    ErrConflict,
+	CodeUnsupported: ErrUnsupported,
+}
+
+// ValidationError 一个参数的错误 Field 为 json 字段名,嵌套字段以.分隔
+type ValidationError struct {
+	Field   string    `json:"field"`   // 参数名
+	Code    ErrorCode `json:"code"`    // 错误码
+	Message string    `json:"message"` // 错误说明
+}
+
+func newValidationError(field string, code ErrorCode, message string) *ValidationError {
+	return &ValidationError{Field: field, Code: code, Message: message}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Is 与错误码对应的哨兵错误相同
+func (e *ValidationError) Is(target error) bool {
+	return codeErrors[e.Code] == target
+}
+
+// ValidationErrors 一次检查发现的全部参数错误
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap 使 errors.Is 和 errors.As 可以匹配其中任意一个参数错误
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (e *ValidationErrors) add(field string, code ErrorCode, message string) {
+	*e = append(*e, newValidationError(field, code, message))
+}
+
+// 合并子检查返回的错误,不是参数错误的按取值错误处理
+func (e *ValidationErrors) merge(err error) {
+	var validationErrors ValidationErrors
+	var validationError *ValidationError
+	switch {
+	case err == nil:
+	case errors.As(err, &validationErrors):
+		*e = append(*e, validationErrors...)
+	case errors.As(err, &validationError):
+		*e = append(*e, validationError)
+	default:
+		e.add("", CodeInvalid, err.Error())
+	}
+}
+
+// 没有错误时返回 nil,避免返回带类型的空值
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,54 @@
+package plan
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+/**
+  *@Description 参数检查一次返回全部参数错误,可用 errors.Is 按类别判断
+**/
+func Test_checkValidationErrors(t *testing.T) {
+	request := &Request{
+		LoanAmount:     decimal.NewFromFloat(-1),
+		LoanStartDate:  "2022-01-01",
+		LoanEndDate:    "2021-01-01",
+		InterestRate:   decimal.NewFromFloat(4.9),
+		PeriodNum:      12,
+		RepayDay:       40,
+		LoanCycleCode:  LoanCycleMonthly,
+		RepayMethod:    EqualLoanRepayment,
+		PeriodType:     PeriodTypeMonth,
+		RoundingPolicy: RoundingPolicy{Mode: "ceiling"},
+	}
+	_, err := CalculateRepaymentPlan(request)
+	var validationErrors ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("got %v, want ValidationErrors", err)
+	}
+	want := []struct {
+		field string
+		code  ErrorCode
+	}{
+		{"roundingPolicy.mode", CodeInvalid},
+		{"loanAmount", CodeInvalid},
+		{"loanEndDate", CodeConflict},
+		{"repayDay", CodeInvalid},
+	}
+	if len(validationErrors) != len(want) {
+		t.Fatalf("got %v", validationErrors)
+	}
+	for i, w := range want {
+		if validationErrors[i].Field != w.field || validationErrors[i].Code != w.code {
+			t.Errorf("error %d: got %+v, want %s %s", i, validationErrors[i], w.field, w.code)
+		}
+	}
+	if !errors.Is(err, ErrInvalid) || !errors.Is(err, ErrConflict) || errors.Is(err, ErrRequired) {
+		t.Errorf("unexpected errors.Is result for %v", err)
+	}
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) || validationError.Field != "roundingPolicy.mode" {
+		t.Errorf("errors.As got %+v", validationError)
+	}
+}